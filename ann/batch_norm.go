@@ -0,0 +1,203 @@
+package ann
+
+import (
+	"math"
+	"math/rand"
+)
+
+type (
+	// BatchNormLayer normalizes each output of the previous layer to zero mean
+	// and unit variance over a training batch, then applies a learned scale
+	// and shift followed by its activation function. Keeping the inputs of
+	// each layer centered lets deeper sigmoid and tanh networks train with
+	// larger learning rates. Predictions use running averages of the batch
+	// statistics instead, so they do not depend on the rest of the batch. See
+	// Ioffe and Szegedy "Batch Normalization: Accelerating Deep Network
+	// Training by Reducing Internal Covariate Shift", 2015.
+	//
+	// The previous layer is usually a DenseLayer without an activation
+	// function. Training batches of a single example normalize every output
+	// to 0, so the layer is meant for use with TrainBatch or Fit with a batch
+	// size larger than one.
+	BatchNormLayer struct {
+		// Weight of the previous running statistics when averaging in those of
+		// a new batch
+		Momentum float64
+
+		// Added to the variance to avoid dividing by 0
+		Epsilon float64
+
+		size           int
+		activationFunc ActivationFunction
+
+		scale          []float64
+		shift          []float64
+		scaleGradients []float64
+		shiftGradients []float64
+		mean           []float64
+		variance       []float64
+	}
+
+	batchNormCache struct {
+		normalized *matrix
+		signal     *matrix
+		invStd     []float64
+	}
+)
+
+// A layer normalizing the outputs of the layer before it. A nil activation
+// function outputs the scaled and shifted values unchanged.
+func NewBatchNormLayer(activationFunc ActivationFunction) *BatchNormLayer {
+	return &BatchNormLayer{Momentum: 0.9, Epsilon: 1e-5, activationFunc: activationFunc}
+}
+
+func (l *BatchNormLayer) Size() int {
+	return l.size
+}
+
+func (l *BatchNormLayer) connect(inputs int, rng *rand.Rand) {
+	l.size = inputs
+	l.scale = make([]float64, inputs)
+	l.shift = make([]float64, inputs)
+	l.scaleGradients = make([]float64, inputs)
+	l.shiftGradients = make([]float64, inputs)
+	l.mean = make([]float64, inputs)
+	l.variance = make([]float64, inputs)
+	for j := range l.scale {
+		l.scale[j] = 1
+		l.variance[j] = 1
+	}
+}
+
+func (l *BatchNormLayer) forward(in *matrix, training bool) (*matrix, interface{}) {
+	mean, variance := l.mean, l.variance
+	if training {
+		mean = make([]float64, in.cols)
+		variance = make([]float64, in.cols)
+		for i := 0; i < in.rows; i++ {
+			for j, x := range in.row(i) {
+				mean[j] += x
+			}
+		}
+		for j := range mean {
+			mean[j] /= float64(in.rows)
+		}
+		for i := 0; i < in.rows; i++ {
+			for j, x := range in.row(i) {
+				variance[j] += (x - mean[j]) * (x - mean[j])
+			}
+		}
+		for j := range variance {
+			variance[j] /= float64(in.rows)
+			l.mean[j] = l.Momentum*l.mean[j] + (1-l.Momentum)*mean[j]
+			l.variance[j] = l.Momentum*l.variance[j] + (1-l.Momentum)*variance[j]
+		}
+	}
+
+	invStd := make([]float64, in.cols)
+	for j, v := range variance {
+		invStd[j] = 1 / math.Sqrt(v+l.Epsilon)
+	}
+
+	if !training {
+		out := newMatrix(in.rows, in.cols)
+		for i, x := range in.data {
+			j := i % in.cols
+			out.data[i] = l.activate(l.scale[j]*((x-mean[j])*invStd[j]) + l.shift[j])
+		}
+		return out, nil
+	}
+
+	normalized := newMatrix(in.rows, in.cols)
+	signal := newMatrix(in.rows, in.cols)
+	out := newMatrix(in.rows, in.cols)
+	for i, x := range in.data {
+		j := i % in.cols
+		normalized.data[i] = (x - mean[j]) * invStd[j]
+		signal.data[i] = l.scale[j]*normalized.data[i] + l.shift[j]
+		out.data[i] = l.activate(signal.data[i])
+	}
+	return out, &batchNormCache{normalized: normalized, signal: signal, invStd: invStd}
+}
+
+func (l *BatchNormLayer) backward(c interface{}, gradOut *matrix, needGradIn bool) *matrix {
+	cache := c.(*batchNormCache)
+
+	// Gradient with respect to the normalized values before scaling
+	gradNormalized := newMatrix(gradOut.rows, gradOut.cols)
+	for j := range l.scaleGradients {
+		l.scaleGradients[j] = 0
+		l.shiftGradients[j] = 0
+	}
+	for i, g := range gradOut.data {
+		j := i % gradOut.cols
+		g *= l.derivative(cache.signal.data[i])
+		l.scaleGradients[j] += g * cache.normalized.data[i]
+		l.shiftGradients[j] += g
+		gradNormalized.data[i] = g * l.scale[j]
+	}
+
+	if !needGradIn {
+		return nil
+	}
+
+	// Every input of a batch shifts the mean and variance used to normalize
+	// the others, d in_j = invStd_j / n * (n * d norm_j - Σ d norm_j - norm_j * Σ d norm_j * norm_j)
+	sum := make([]float64, gradOut.cols)
+	sumNormalized := make([]float64, gradOut.cols)
+	for i, g := range gradNormalized.data {
+		j := i % gradOut.cols
+		sum[j] += g
+		sumNormalized[j] += g * cache.normalized.data[i]
+	}
+	n := float64(gradOut.rows)
+	gradIn := newMatrix(gradOut.rows, gradOut.cols)
+	for i, g := range gradNormalized.data {
+		j := i % gradOut.cols
+		gradIn.data[i] = cache.invStd[j] / n * (n*g - sum[j] - cache.normalized.data[i]*sumNormalized[j])
+	}
+	return gradIn
+}
+
+func (l *BatchNormLayer) parameters() []Parameters {
+	return []Parameters{
+		{Values: l.scale, Gradients: l.scaleGradients},
+		{Values: l.shift, Gradients: l.shiftGradients},
+	}
+}
+
+func (l *BatchNormLayer) activate(x float64) float64 {
+	if l.activationFunc == nil {
+		return x
+	}
+	return l.activationFunc.Calc(x)
+}
+
+func (l *BatchNormLayer) derivative(x float64) float64 {
+	if l.activationFunc == nil {
+		return 1
+	}
+	return l.activationFunc.CalcDerivative(x)
+}
+
+// Copies of the running statistics of every batch normalization layer, which
+// training passes update
+func (net *FeedForward) runningStatistics() [][]float64 {
+	var stats [][]float64
+	for _, l := range net.layers[1:] {
+		if l, ok := l.(*BatchNormLayer); ok {
+			stats = append(stats, append([]float64(nil), l.mean...), append([]float64(nil), l.variance...))
+		}
+	}
+	return stats
+}
+
+func (net *FeedForward) restoreRunningStatistics(stats [][]float64) {
+	for _, l := range net.layers[1:] {
+		if l, ok := l.(*BatchNormLayer); ok {
+			copy(l.mean, stats[0])
+			copy(l.variance, stats[1])
+			stats = stats[2:]
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package ann
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBatchNormLayer(t *testing.T) {
+	layer := NewBatchNormLayer(nil)
+	layer.connect(2, rand.New(rand.NewSource(1)))
+	in := matrixFromRows([][]float64{{1, 10}, {2, 20}, {3, 30}, {4, 40}})
+
+	out, _ := layer.forward(in, true)
+	for j := 0; j < out.cols; j++ {
+		var mean, variance float64
+		for i := 0; i < out.rows; i++ {
+			mean += out.row(i)[j] / float64(out.rows)
+		}
+		for i := 0; i < out.rows; i++ {
+			variance += (out.row(i)[j] - mean) * (out.row(i)[j] - mean) / float64(out.rows)
+		}
+		if math.Abs(mean) > 1e-9 || math.Abs(variance-1) > 1e-3 {
+			t.Errorf("Output %v has mean %v and variance %v, expected 0 and 1", j, mean, variance)
+		}
+	}
+
+	// The running statistics converge to those of the batches seen while
+	// training, after which predictions match the normalized training batch
+	for i := 0; i < 200; i++ {
+		layer.forward(in, true)
+	}
+	predicted, _ := layer.forward(in, false)
+	for i := range out.data {
+		if math.Abs(predicted.data[i]-out.data[i]) > 1e-3 {
+			t.Fatalf("Prediction %v does not use the running statistics of training %v", predicted.data, out.data)
+		}
+	}
+
+	// A single example is normalized by the running statistics rather than itself
+	single, _ := layer.forward(matrixFromRows([][]float64{{1, 10}}), false)
+	if math.Abs(single.data[0]-out.data[0]) > 1e-3 {
+		t.Errorf("Expected a single prediction of %v, got %v", out.data[0], single.data[0])
+	}
+}
+
+func TestBatchNormGradients(t *testing.T) {
+	net := NewFeedForwardWithOptions(Options{LearningRate: 0.1, Source: rand.NewSource(1)},
+		NewInputLayer(3),
+		NewLayer(4, nil),
+		NewBatchNormLayer(new(TanhActivation)),
+		NewLayer(2, new(SigmoidActivation)),
+	)
+	inputs := [][]float64{{1, 0, 0.5}, {0.2, 0.3, -1}, {-0.5, 1, 0}, {0.7, -0.2, 0.4}}
+	outputs := [][]float64{{1, 0}, {0, 1}, {1, 1}, {0, 0}}
+	// Make the scale and shift differ from their starting values
+	for i := 0; i < 10; i++ {
+		if _, err := net.TrainBatch(inputs, outputs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if largest, err := net.CheckGradients(inputs, outputs); err != nil {
+		t.Fatal(err)
+	} else if largest > 1e-6 {
+		t.Errorf("Analytical gradients differ from numerical gradients by %v", largest)
+	}
+}
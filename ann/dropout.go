@@ -0,0 +1,88 @@
+package ann
+
+import (
+	"math"
+	"math/rand"
+)
+
+type (
+	// DropoutLayer sets a random fraction of the outputs of the previous layer
+	// to 0 on every training batch, which keeps neurons from depending on each
+	// other and reduces overfitting. The remaining outputs are scaled up so
+	// that their expected sum is unchanged, so predictions pass through the
+	// layer untouched. See Srivastava et al. "Dropout: A Simple Way to Prevent
+	// Neural Networks from Overfitting", 2014.
+	DropoutLayer struct {
+		// Fraction of outputs dropped
+		rate float64
+
+		size int
+		rng  *rand.Rand
+	}
+
+	// The scale applied to each output of a training pass, 0 for dropped outputs
+	dropoutCache struct {
+		mask *matrix
+	}
+)
+
+// The largest fraction of outputs a DropoutLayer drops, dropping every output
+// would leave nothing to train on
+const maxDropoutRate = 0.99
+
+// A layer dropping the given fraction of the outputs of the layer before it
+// while training. It has the same size as the layer before it. The rate is
+// clamped to [0, 0.99].
+func NewDropoutLayer(rate float64) *DropoutLayer {
+	return &DropoutLayer{rate: math.Max(0, math.Min(maxDropoutRate, rate))}
+}
+
+// The fraction of outputs dropped while training
+func (l *DropoutLayer) Rate() float64 {
+	return l.rate
+}
+
+func (l *DropoutLayer) Size() int {
+	return l.size
+}
+
+func (l *DropoutLayer) connect(inputs int, rng *rand.Rand) {
+	l.size = inputs
+	l.rng = rng
+}
+
+func (l *DropoutLayer) forward(in *matrix, training bool) (*matrix, interface{}) {
+	if !training || l.rate == 0 {
+		return in, nil
+	}
+
+	mask := newMatrix(in.rows, in.cols)
+	out := newMatrix(in.rows, in.cols)
+	scale := 1 / (1 - l.rate)
+	for i, x := range in.data {
+		if l.rng.Float64() >= l.rate {
+			mask.data[i] = scale
+			out.data[i] = x * scale
+		}
+	}
+	return out, &dropoutCache{mask: mask}
+}
+
+func (l *DropoutLayer) backward(c interface{}, gradOut *matrix, needGradIn bool) *matrix {
+	if !needGradIn {
+		return nil
+	}
+	if c == nil {
+		return gradOut
+	}
+	cache := c.(*dropoutCache)
+	gradIn := newMatrix(gradOut.rows, gradOut.cols)
+	for i, g := range gradOut.data {
+		gradIn.data[i] = g * cache.mask.data[i]
+	}
+	return gradIn
+}
+
+func (l *DropoutLayer) parameters() []Parameters {
+	return nil
+}
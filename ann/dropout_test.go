@@ -0,0 +1,128 @@
+package ann
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestDropoutLayer(t *testing.T) {
+	layer := NewDropoutLayer(0.25)
+	layer.connect(1000, rand.New(rand.NewSource(1)))
+	in := newMatrix(2, 1000)
+	for i := range in.data {
+		in.data[i] = 1
+	}
+
+	// Predictions pass through untouched
+	if out, _ := layer.forward(in, false); !reflect.DeepEqual(out, in) {
+		t.Fatal("Dropout changed outputs outside of training")
+	}
+
+	out, cache := layer.forward(in, true)
+	var dropped int
+	var sum float64
+	for _, x := range out.data {
+		if x == 0 {
+			dropped++
+		} else if math.Abs(x-4.0/3) > 1e-12 {
+			t.Fatalf("Expected kept outputs to be scaled by 1 / (1 - rate), got %v", x)
+		}
+		sum += x
+	}
+	if rate := float64(dropped) / float64(len(out.data)); math.Abs(rate-0.25) > 0.03 {
+		t.Errorf("Dropped %v of outputs, expected 0.25", rate)
+	}
+	if mean := sum / float64(len(out.data)); math.Abs(mean-1) > 0.05 {
+		t.Errorf("Expected the mean output to stay close to 1, got %v", mean)
+	}
+
+	// Dropped outputs pass no gradient back
+	gradIn := layer.backward(cache, in, true)
+	if !reflect.DeepEqual(gradIn, out) {
+		t.Error("Dropout gradient does not match the dropped outputs")
+	}
+}
+
+func TestDropoutRateIsClamped(t *testing.T) {
+	for rate, expected := range map[float64]float64{-0.5: 0, 0: 0, 0.5: 0.5, 1: maxDropoutRate, 2: maxDropoutRate} {
+		if r := NewDropoutLayer(rate).Rate(); r != expected {
+			t.Errorf("Expected a rate of %v to be clamped to %v, got %v", rate, expected, r)
+		}
+	}
+
+	// Clamped rates can be saved and loaded
+	net := NewFeedForward(0.1, NewInputLayer(2), NewDropoutLayer(1), NewLayer(1, nil))
+	var buf bytes.Buffer
+	if err := net.Save(&buf, JSON); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFeedForward(&buf, JSON); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWeightDecay(t *testing.T) {
+	inputs := [][]float64{{1, 0}, {0, 1}}
+	outputs := [][]float64{{0}, {0}}
+	for name, test := range map[string]struct {
+		options  LayerOptions
+		expected func(w float64) float64
+	}{
+		"l1": {LayerOptions{L1: 0.1}, func(w float64) float64 { return sign(w) * 0.1 }},
+		"l2": {LayerOptions{L2: 0.1}, func(w float64) float64 { return w * 0.1 }},
+	} {
+		layer := NewLayerWithOptions(1, nil, test.options)
+		net := NewFeedForwardWithOptions(Options{LearningRate: 0.1, Source: rand.NewSource(1)}, NewInputLayer(2), layer)
+
+		// The same network without penalties gives the gradient of the loss alone
+		plain := NewFeedForwardWithOptions(Options{LearningRate: 0.1, Source: rand.NewSource(1)}, NewInputLayer(2), NewLayer(1, nil))
+		if _, err := net.gradients(inputs, outputs); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := plain.gradients(inputs, outputs); err != nil {
+			t.Fatal(err)
+		}
+		penalized := net.parameters()[0]
+		unpenalized := plain.parameters()[0]
+		for i, w := range penalized.Values {
+			if d := penalized.Gradients[i] - unpenalized.Gradients[i]; math.Abs(d-test.expected(w)) > 1e-12 {
+				t.Errorf("%v: expected a penalty of %v on weight %v, got %v", name, test.expected(w), w, d)
+			}
+		}
+		if !reflect.DeepEqual(net.parameters()[1].Gradients, plain.parameters()[1].Gradients) {
+			t.Errorf("%v: biases were penalized", name)
+		}
+	}
+}
+
+func TestSaveLoadRegularizedNetwork(t *testing.T) {
+	net := NewFeedForwardWithOptions(Options{LearningRate: 0.1, Source: rand.NewSource(1)},
+		NewInputLayer(64),
+		NewLayerWithOptions(16, new(SigmoidActivation), LayerOptions{L1: 0.01, L2: 0.001}),
+		NewDropoutLayer(0.5),
+		NewLayer(10, new(SigmoidActivation)),
+	)
+	inputs, _ := randomDigits(4)
+	expected, _ := net.PredictBatch(inputs)
+
+	var buf bytes.Buffer
+	if err := net.Save(&buf, JSON); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFeedForward(&buf, JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if predictions, _ := loaded.PredictBatch(inputs); !reflect.DeepEqual(predictions, expected) {
+		t.Error("Loaded network predicts differently")
+	}
+	if l := loaded.layers[1].(*DenseLayer); l.l1 != 0.01 || l.l2 != 0.001 {
+		t.Errorf("Penalties not restored: %v, %v", l.l1, l.l2)
+	}
+	if l := loaded.layers[2].(*DropoutLayer); l.Rate() != 0.5 || l.Size() != 16 {
+		t.Errorf("Dropout not restored: %+v", l)
+	}
+}
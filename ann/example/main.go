@@ -10,22 +10,34 @@ import (
 )
 
 func main() {
-	net := ann.NewFeedForward(0.1,
+	net := ann.NewFeedForwardWithOptions(
+		ann.Options{LearningRate: 0.1, Loss: new(ann.CategoricalCrossEntropy)},
 		ann.NewInputLayer(64),
 		ann.NewLayer(64, new(ann.SigmoidActivation)),
-		ann.NewLayer(10, new(ann.SigmoidActivation)),
+		ann.NewSoftmaxLayer(10),
 	)
 
 	trainingInputs, trainingClasses := readData("optdigits.tra")
 	testingInputs, testingClasses := readData("optdigits.tes")
 
-	for j := 0; j < 100; j++ {
-		for i := range trainingInputs {
-			_, err := net.Train(trainingInputs[i], makeOutput(trainingClasses[i]))
-			if err != nil {
-				panic(err)
+	trainingOutputs := make([][]float64, len(trainingClasses))
+	for i, class := range trainingClasses {
+		trainingOutputs[i] = makeOutput(class)
+	}
+
+	_, err := net.Fit(trainingInputs, trainingOutputs, ann.FitOptions{
+		Epochs:          100,
+		ValidationSplit: 0.1,
+		Patience:        10,
+		Callbacks: []func(ann.History) bool{func(history ann.History) bool {
+			if epoch := history[len(history)-1]; len(history)%10 == 0 {
+				fmt.Printf("Epoch %v: loss %.4f, validation accuracy %.4f\n", len(history), epoch.Loss, epoch.ValidationAccuracy)
 			}
-		}
+			return false
+		}},
+	})
+	if err != nil {
+		panic(err)
 	}
 
 	correct := 0
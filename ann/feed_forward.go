@@ -4,6 +4,7 @@ import (
 	"errors"
 	"math"
 	"math/rand"
+	"sync"
 )
 
 type ActivationFunction interface {
@@ -18,7 +19,8 @@ func (a *SigmoidActivation) Calc(x float64) float64 {
 }
 
 func (a *SigmoidActivation) CalcDerivative(x float64) float64 {
-	return a.Calc(x) * (1 - a.Calc(x))
+	res := a.Calc(x)
+	return res * (1 - res)
 }
 
 type ReLUActivation struct{}
@@ -27,8 +29,12 @@ func (a *ReLUActivation) Calc(x float64) float64 {
 	return math.Max(0, x)
 }
 
+// The derivative is taken to be 0 where x is 0
 func (a *ReLUActivation) CalcDerivative(x float64) float64 {
-	return math.Max(0, x) / x
+	if x > 0 {
+		return 1
+	}
+	return 0
 }
 
 type TanhActivation struct{}
@@ -44,177 +50,261 @@ func (a *TanhActivation) CalcDerivative(x float64) float64 {
 
 type FeedForward struct {
 	LearningRate float64
-	layers       []Layer
-}
 
-type Layer []*Neuron
+	// Applies the gradients of each batch to the weights
+	Optimizer Optimizer
+
+	// The loss minimized by training
+	Loss Loss
+
+	layers []Layer
+	rng    *rand.Rand
 
-type Neuron struct {
-	activationFunc ActivationFunction
-	activation     float64
-	signal         float64
-	delta          float64
-	in             []*edge
-	out            []*edge
+	// Training holds the write lock and prediction the read lock, so that a
+	// network can serve predictions from many goroutines while it trains.
+	// Copies of a network share its layers and so its lock.
+	mu *sync.RWMutex
 }
 
-type edge struct {
-	weight float64
-	in     *Neuron
-	out    *Neuron
+// Options configure a FeedForward network beyond its layers
+type Options struct {
+	LearningRate float64
+
+	// nil uses plain gradient descent
+	Optimizer Optimizer
+
+	// nil uses MeanSquaredError
+	Loss Loss
+
+	// Source of all randomness of the network, such as its starting weights.
+	// The same seed trains the same network. nil seeds a source from math/rand.
+	Source rand.Source
 }
 
 var InputDimensionMismatchError = errors.New("Dimension of input must match the dimension on the input layer")
 var OutputDimensionMismatchError = errors.New("Dimension of output must match the dimension on the output layer")
 var NotInitializedError = errors.New("At least 2 layers must be added to the nextwork before training of predicting")
-
+var BatchSizeMismatchError = errors.New("Number of inputs must match the number of outputs and be at least 1")
+var ValidationSplitError = errors.New("Validation split must be in [0, 1) and leave at least one training example")
+var UnknownFormatError = errors.New("Unknown network encoding format")
+var UnsupportedVersionError = errors.New("Saved network version is not supported by this package")
+var UnsupportedActivationError = errors.New("Only activation functions provided by this package can be saved")
+var UnsupportedLossError = errors.New("Only losses provided by this package can be saved")
+var UnsupportedLayerError = errors.New("Saved network contains an unknown layer type")
+var InvalidNetworkError = errors.New("Saved network has parameters which do not match its layer sizes")
+
+// A network trained with plain gradient descent
 func NewFeedForward(learningRate float64, layers ...Layer) FeedForward {
-	net := FeedForward{LearningRate: learningRate, layers: layers}
-
-	// Connect layers
-	for i := 0; i < len(net.layers)-1; i++ {
-		this := net.layers[i]
-		next := net.layers[i+1]
-
-		// Initialize edge slices
-		for _, n := range this {
-			n.out = make([]*edge, 0, len(next))
-		}
-		for _, n := range next {
-			n.in = make([]*edge, 0, len(this))
-		}
+	return NewFeedForwardWithOptions(Options{LearningRate: learningRate}, layers...)
+}
 
-		// Connect layers and initialize weights
-		for _, thisNode := range this {
-			for _, nextNode := range next {
-				edge := &edge{in: thisNode, out: nextNode}
-				for edge.weight == 0 {
-					edge.weight = rand.NormFloat64()
-				}
-				edge.in.out = append(edge.in.out, edge)
-				edge.out.in = append(edge.out.in, edge)
-			}
-		}
+func NewFeedForwardWithOptions(options Options, layers ...Layer) FeedForward {
+	net := FeedForward{
+		LearningRate: options.LearningRate,
+		Optimizer:    options.Optimizer,
+		Loss:         options.Loss,
+		layers:       layers,
+		mu:           new(sync.RWMutex),
 	}
+	if net.Optimizer == nil {
+		net.Optimizer = new(SGD)
+	}
+	if net.Loss == nil {
+		net.Loss = new(MeanSquaredError)
+	}
+	source := options.Source
+	if source == nil {
+		source = rand.NewSource(rand.Int63())
+	}
+	net.rng = rand.New(source)
 
-	// Initialize and add bias units
-	for i := 0; i < len(net.layers)-1; i++ {
-		next := net.layers[i+1]
-
-		bias := &Neuron{activationFunc: nil, signal: 1, activation: 1}
-		bias.out = make([]*edge, 0, len(next))
-
-		for _, nextNode := range next {
-			edge := &edge{in: bias, out: nextNode}
-			for edge.weight == 0 {
-				edge.weight = rand.Float64()
-			}
-			edge.in.out = append(edge.in.out, edge)
-			edge.out.in = append(edge.out.in, edge)
-		}
-
-		net.layers[i] = append(net.layers[i], bias)
+	// Connect each layer to the outputs of the previous layer
+	for i := 1; i < len(net.layers); i++ {
+		net.layers[i].connect(net.layers[i-1].Size(), net.rng)
 	}
 
 	return net
 }
 
-func NewLayer(numNeurons int, activationFunc ActivationFunction) Layer {
-	layer := Layer(make([]*Neuron, numNeurons))
-	for i := 0; i < len(layer); i++ {
-		layer[i] = &Neuron{activationFunc: activationFunc}
+// Train the network on a single example. Returns the squared error of each
+// output before the weights were adjusted, whichever loss is minimized.
+func (net *FeedForward) Train(in []float64, out []float64) ([]float64, error) {
+	if err := net.lock(); err != nil {
+		return []float64{}, err
+	}
+	defer net.mu.Unlock()
+
+	prediction, err := net.train([][]float64{in}, [][]float64{out})
+	if err != nil {
+		return []float64{}, err
 	}
-	return layer
-}
 
-func NewInputLayer(numNeurons int) Layer {
-	return NewLayer(numNeurons, nil)
+	// Return a square error to the caller so that they can choose when to stop training
+	sqError := make([]float64, len(out))
+	for i := 0; i < len(out); i++ {
+		sqError[i] = (prediction.data[i] - out[i]) * (prediction.data[i] - out[i])
+	}
+	return sqError, nil
 }
 
-func (net *FeedForward) Train(in []float64, out []float64) ([]float64, error) {
-	prediction, err := net.Predict(in)
+// Train the network on a batch of examples, adjusting the weights along the
+// negative of the loss gradient averaged over the batch. Returns the mean
+// loss of the batch before the weights were adjusted.
+func (net *FeedForward) TrainBatch(inputs [][]float64, outputs [][]float64) (float64, error) {
+	if err := net.lock(); err != nil {
+		return 0, err
+	}
+	defer net.mu.Unlock()
+
+	prediction, err := net.train(inputs, outputs)
 	if err != nil {
-		return []float64{}, err
+		return 0, err
 	}
-	if len(out) != len(prediction) {
-		return []float64{}, OutputDimensionMismatchError
+
+	var loss float64
+	for i := 0; i < prediction.rows; i++ {
+		loss += net.Loss.Loss(prediction.row(i), outputs[i])
 	}
+	return loss / float64(prediction.rows), nil
+}
 
-	// Calculate gradients for output nodes
-	outputLayer := net.layers[len(net.layers)-1]
-	for i, n := range outputLayer {
-		// Derivative of ((f(x) - y)^2) / 2
-		n.delta = (prediction[i] - out[i]) * n.activationFunc.CalcDerivative(n.signal)
+// Run one step of gradient descent on a batch, returning the predictions made before the step
+func (net *FeedForward) train(inputs [][]float64, outputs [][]float64) (*matrix, error) {
+	prediction, err := net.gradients(inputs, outputs)
+	if err != nil {
+		return nil, err
 	}
 
-	// Calculate gradients for rest of nodes
-	for i := len(net.layers) - 2; i > 0; i-- {
-		for _, n := range net.layers[i] {
-			if n.activationFunc != nil {
-				var sum float64
-				for _, e := range n.out {
-					sum += e.out.delta * e.weight
-				}
-				n.delta = n.activationFunc.CalcDerivative(n.signal) * sum
-			}
+	// Adjust weights along the negative of the gradient
+	net.Optimizer.Update(net.LearningRate, net.parameters())
+
+	return prediction, nil
+}
+
+// Set the gradients of every parameter to those of the loss averaged over a
+// batch, returning the predictions for the batch
+func (net *FeedForward) gradients(inputs [][]float64, outputs [][]float64) (*matrix, error) {
+	if len(inputs) != len(outputs) || len(inputs) == 0 {
+		return nil, BatchSizeMismatchError
+	}
+	if err := net.checkInputs(inputs); err != nil {
+		return nil, err
+	}
+	for _, out := range outputs {
+		if len(out) != net.layers[len(net.layers)-1].Size() {
+			return nil, OutputDimensionMismatchError
 		}
 	}
 
-	// Adjust weights along the negative of the gradient
-	for i := len(net.layers) - 2; i >= 0; i-- {
-		for _, n := range net.layers[i] {
-			for _, e := range n.out {
-				e.weight -= net.LearningRate * e.in.activation * e.out.delta
+	prediction, caches := net.forward(matrixFromRows(inputs), true)
+
+	last := len(net.layers) - 1
+	grad := newMatrix(prediction.rows, prediction.cols)
+	if softmax, ok := net.layers[last].(*SoftmaxLayer); ok && isCategoricalCrossEntropy(net.Loss) {
+		// The gradient of cross entropy through softmax with respect to the
+		// weighted sums is the prediction minus the target, which avoids
+		// dividing by predicted probabilities close to 0
+		for i := 0; i < grad.rows; i++ {
+			for j, p := range prediction.row(i) {
+				grad.row(i)[j] = (p - outputs[i][j]) / float64(grad.rows)
 			}
 		}
+		grad = softmax.backwardSignal(caches[last], grad, last > 1)
+		last--
+	} else {
+		for i := 0; i < grad.rows; i++ {
+			net.Loss.Gradient(prediction.row(i), outputs[i], grad.row(i))
+		}
+		for i := range grad.data {
+			grad.data[i] /= float64(grad.rows)
+		}
 	}
 
-	// Return a square error to the caller so that they can choose when to stop training
-	sqError := make([]float64, len(out))
-	for i := 0; i < len(out); i++ {
-		sqError[i] = (prediction[i] - out[i]) * (prediction[i] - out[i])
+	// Backpropagate the gradient through the remaining layers
+	for i := last; i > 0; i-- {
+		grad = net.layers[i].backward(caches[i], grad, i > 1)
 	}
-	return sqError, nil
+
+	return prediction, nil
+}
+
+func isCategoricalCrossEntropy(loss Loss) bool {
+	_, ok := loss.(*CategoricalCrossEntropy)
+	return ok
 }
 
 func (net *FeedForward) Predict(in []float64) ([]float64, error) {
-	// Make sure network is initialized
-	if len(net.layers) < 2 {
-		return []float64{}, NotInitializedError
+	out, err := net.PredictBatch([][]float64{in})
+	if err != nil {
+		return []float64{}, err
 	}
+	return out[0], nil
+}
 
-	// -1 adjusts for bias node
-	if len(in) != len(net.layers[0])-1 {
-		return []float64{}, InputDimensionMismatchError
+// Predict the outputs of a batch of inputs. Any number of goroutines may
+// predict at once, each pass keeps its intermediate values to itself.
+func (net *FeedForward) PredictBatch(inputs [][]float64) ([][]float64, error) {
+	if err := net.rlock(); err != nil {
+		return nil, err
 	}
+	defer net.mu.RUnlock()
 
-	// Activate the first layer
-	for i := 0; i < len(in); i++ {
-		n := net.layers[0][i]
-		n.signal = in[i]
-		n.activation = n.signal
+	if err := net.checkInputs(inputs); err != nil {
+		return nil, err
 	}
+	out, _ := net.forward(matrixFromRows(inputs), false)
+	return out.toRows(), nil
+}
+
+// The parameters of every layer, always in the same order
+func (net *FeedForward) parameters() []Parameters {
+	var params []Parameters
+	for _, l := range net.layers[1:] {
+		params = append(params, l.parameters()...)
+	}
+	return params
+}
 
-	// Feed forward
+// Feed a batch forward through the network, returning the output of the last
+// layer and the cache of every layer for backpropagation
+func (net *FeedForward) forward(in *matrix, training bool) (*matrix, []interface{}) {
+	caches := make([]interface{}, len(net.layers))
+	out := in
 	for i := 1; i < len(net.layers); i++ {
-		for _, n := range net.layers[i] {
-			if n.activationFunc != nil {
-				var sum float64
-				for _, e := range n.in {
-					sum += e.in.activation * e.weight
-				}
-				n.signal = sum
-				n.activation = n.activationFunc.Calc(n.signal)
-			}
+		out, caches[i] = net.layers[i].forward(out, training)
+	}
+	return out, caches
+}
+
+// Make sure the network is initialized and every input matches the input layer
+func (net *FeedForward) checkInputs(inputs [][]float64) error {
+	if len(net.layers) < 2 {
+		return NotInitializedError
+	}
+	if len(inputs) == 0 {
+		return BatchSizeMismatchError
+	}
+	for _, in := range inputs {
+		if len(in) != net.layers[0].Size() {
+			return InputDimensionMismatchError
 		}
 	}
+	return nil
+}
 
-	// Grab output
-	outputLayer := net.layers[len(net.layers)-1]
-	out := make([]float64, len(outputLayer))
-	for i, n := range outputLayer {
-		out[i] = n.activation
+// Take the write lock, only networks without layers have no lock
+func (net *FeedForward) lock() error {
+	if net.mu == nil {
+		return NotInitializedError
 	}
+	net.mu.Lock()
+	return nil
+}
 
-	return out, nil
+func (net *FeedForward) rlock() error {
+	if net.mu == nil {
+		return NotInitializedError
+	}
+	net.mu.RLock()
+	return nil
 }
@@ -1 +1,207 @@
 package ann
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// A 64-64-10 sigmoid network like the optdigits example
+func newDigitsNet() FeedForward {
+	return NewFeedForward(0.1,
+		NewInputLayer(64),
+		NewLayer(64, new(SigmoidActivation)),
+		NewLayer(10, new(SigmoidActivation)),
+	)
+}
+
+// Random inputs and one hot outputs in the shape of the optdigits data
+func randomDigits(n int) ([][]float64, [][]float64) {
+	inputs := make([][]float64, n)
+	outputs := make([][]float64, n)
+	for i := range inputs {
+		inputs[i] = make([]float64, 64)
+		for j := range inputs[i] {
+			inputs[i][j] = rand.Float64()
+		}
+		outputs[i] = make([]float64, 10)
+		outputs[i][rand.Intn(10)] = 1
+	}
+	return inputs, outputs
+}
+
+// Give every layer of b the parameters of the matching layer of a
+func copyParameters(a, b *FeedForward) {
+	for i := 1; i < len(a.layers); i++ {
+		pb := b.layers[i].parameters()
+		for j, p := range a.layers[i].parameters() {
+			copy(pb[j].Values, p.Values)
+		}
+	}
+}
+
+func TestTrainBatchOfOneMatchesTrain(t *testing.T) {
+	single := newDigitsNet()
+	batch := newDigitsNet()
+	copyParameters(&single, &batch)
+
+	inputs, outputs := randomDigits(5)
+	for i := range inputs {
+		if _, err := single.Train(inputs[i], outputs[i]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := batch.TrainBatch(inputs[i:i+1], outputs[i:i+1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, in := range inputs {
+		a, _ := single.Predict(in)
+		b, _ := batch.Predict(in)
+		for j := range a {
+			if math.Abs(a[j]-b[j]) > 1e-12 {
+				t.Fatalf("Predictions differ: %v != %v", a, b)
+			}
+		}
+	}
+}
+
+func TestTrainBatchLinearRegression(t *testing.T) {
+	net := NewFeedForward(0.1, NewInputLayer(2), NewLayer(1, nil))
+	inputs := [][]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0.5, 0.2}}
+	outputs := make([][]float64, len(inputs))
+	for i, in := range inputs {
+		outputs[i] = []float64{2*in[0] - in[1] + 0.5}
+	}
+
+	var loss float64
+	for i := 0; i < 2000; i++ {
+		var err error
+		if loss, err = net.TrainBatch(inputs, outputs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if loss > 1e-8 {
+		t.Errorf("Expected linear fit, loss = %v", loss)
+	}
+
+	predictions, err := net.PredictBatch([][]float64{{2, 2}})
+	if err != nil || math.Abs(predictions[0][0]-2.5) > 1e-3 {
+		t.Errorf("Expected 2.5, got %v, error = %v", predictions, err)
+	}
+}
+
+func TestFeedForwardErrors(t *testing.T) {
+	net := newDigitsNet()
+	inputs, outputs := randomDigits(2)
+
+	if _, err := net.Predict(make([]float64, 3)); err != InputDimensionMismatchError {
+		t.Errorf("Expected InputDimensionMismatchError, got %v", err)
+	}
+	if _, err := net.Train(inputs[0], make([]float64, 3)); err != OutputDimensionMismatchError {
+		t.Errorf("Expected OutputDimensionMismatchError, got %v", err)
+	}
+	if _, err := net.TrainBatch(inputs, outputs[:1]); err != BatchSizeMismatchError {
+		t.Errorf("Expected BatchSizeMismatchError, got %v", err)
+	}
+	empty := NewFeedForward(0.1, NewInputLayer(64))
+	if _, err := empty.Predict(inputs[0]); err != NotInitializedError {
+		t.Errorf("Expected NotInitializedError, got %v", err)
+	}
+}
+
+func TestConcurrentPredict(t *testing.T) {
+	net := NewFeedForwardWithOptions(Options{LearningRate: 0.1, Source: rand.NewSource(1)},
+		NewInputLayer(64),
+		NewLayer(32, nil),
+		NewBatchNormLayer(new(TanhActivation)),
+		NewDropoutLayer(0.2),
+		NewSoftmaxLayer(10),
+	)
+	inputs, outputs := randomDigits(32)
+	for i := 0; i < 5; i++ {
+		if _, err := net.TrainBatch(inputs, outputs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, _ := net.PredictBatch(inputs)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := 0; n < 20; n++ {
+				i := (g + n) % len(inputs)
+				out, err := net.Predict(inputs[i])
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !reflect.DeepEqual(out, expected[i]) {
+					errs <- fmt.Errorf("Concurrent prediction %v differs from %v", out, expected[i])
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestPredictWhileTraining(t *testing.T) {
+	net := newDigitsNet()
+	inputs, outputs := randomDigits(16)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := net.Fit(inputs, outputs, FitOptions{Epochs: 5, BatchSize: 4, ValidationSplit: 0.25}); err != nil {
+			t.Error(err)
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		if _, err := net.Predict(inputs[i%len(inputs)]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := net.Train(inputs[0], outputs[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
+
+func BenchmarkTrain(b *testing.B) {
+	net := newDigitsNet()
+	inputs, outputs := randomDigits(32)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		net.Train(inputs[i%32], outputs[i%32])
+	}
+}
+
+// Reports the time per example so that it compares with BenchmarkTrain
+func BenchmarkTrainBatch(b *testing.B) {
+	net := newDigitsNet()
+	inputs, outputs := randomDigits(32)
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 32 {
+		net.TrainBatch(inputs, outputs)
+	}
+}
+
+func BenchmarkPredict(b *testing.B) {
+	net := newDigitsNet()
+	inputs, _ := randomDigits(32)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		net.Predict(inputs[i%32])
+	}
+}
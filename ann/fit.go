@@ -0,0 +1,219 @@
+package ann
+
+type (
+	// FitOptions configure the training loop of FeedForward.Fit
+	FitOptions struct {
+		// Number of passes over the training examples, 0 trains a single epoch
+		Epochs int
+
+		// Number of examples per gradient step, 0 trains on one example at a
+		// time like Train
+		BatchSize int
+
+		// Fraction of the examples held out of training to measure how well
+		// the network generalizes. The examples are taken from the end of the
+		// dataset before shuffling, so the split is the same on every call.
+		ValidationSplit float64
+
+		// Stop once the loss has not improved for this many epochs and restore
+		// the weights of the epoch with the lowest loss, also when training
+		// fails. The validation loss is used when there is a validation split.
+		// 0 trains every epoch.
+		Patience int
+
+		// Called after every epoch with the history so far. Training stops
+		// early if a callback returns true.
+		Callbacks []func(history History) (stop bool)
+	}
+
+	// Epoch holds the mean loss and accuracy of one epoch of Fit. The
+	// training values are measured on each batch before its gradient step.
+	// Accuracy is the fraction of examples whose largest output matches the
+	// largest target, or whose output rounds to the target for networks with
+	// a single output.
+	Epoch struct {
+		Loss               float64
+		Accuracy           float64
+		ValidationLoss     float64
+		ValidationAccuracy float64
+	}
+
+	// The epochs of a call to Fit in order
+	History []Epoch
+)
+
+// Train the network for a number of epochs, each a pass over the examples in
+// a new random order. Returns the loss and accuracy of every epoch. The
+// network is locked one batch at a time, so it keeps serving predictions
+// while it trains and callbacks may use it.
+func (net *FeedForward) Fit(inputs [][]float64, outputs [][]float64, options FitOptions) (History, error) {
+	if len(inputs) != len(outputs) || len(inputs) == 0 {
+		return nil, BatchSizeMismatchError
+	}
+	if err := net.checkInputs(inputs); err != nil {
+		return nil, err
+	}
+	// Validation outputs never reach train, so check every output here
+	for _, out := range outputs {
+		if len(out) != net.layers[len(net.layers)-1].Size() {
+			return nil, OutputDimensionMismatchError
+		}
+	}
+
+	validation := int(options.ValidationSplit * float64(len(inputs)))
+	if options.ValidationSplit < 0 || validation >= len(inputs) {
+		return nil, ValidationSplitError
+	}
+	training := len(inputs) - validation
+	validationInputs, validationOutputs := inputs[training:], outputs[training:]
+
+	epochs := options.Epochs
+	if epochs <= 0 {
+		epochs = 1
+	}
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	var history History
+	var best []Parameters
+	var bestStatistics [][]float64
+	bestLoss, bestEpoch := 0.0, -1
+	order := make([]int, training)
+	for i := range order {
+		order[i] = i
+	}
+	for epoch := 0; epoch < epochs; epoch++ {
+		net.mu.Lock()
+		net.rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		net.mu.Unlock()
+
+		var e Epoch
+		for start := 0; start < training; start += batchSize {
+			end := start + batchSize
+			if end > training {
+				end = training
+			}
+			batchInputs := make([][]float64, 0, end-start)
+			batchOutputs := make([][]float64, 0, end-start)
+			for _, i := range order[start:end] {
+				batchInputs = append(batchInputs, inputs[i])
+				batchOutputs = append(batchOutputs, outputs[i])
+			}
+
+			loss, correct, err := net.fitBatch(batchInputs, batchOutputs)
+			if err != nil {
+				net.restoreBest(best, bestStatistics)
+				return history, err
+			}
+			e.Loss += loss
+			e.Accuracy += correct
+		}
+		e.Loss /= float64(training)
+		e.Accuracy /= float64(training)
+
+		loss := e.Loss
+		if validation > 0 {
+			net.mu.RLock()
+			prediction, _ := net.forward(matrixFromRows(validationInputs), false)
+			e.ValidationLoss, e.ValidationAccuracy = net.evaluate(prediction, validationOutputs)
+			net.mu.RUnlock()
+			e.ValidationLoss /= float64(validation)
+			e.ValidationAccuracy /= float64(validation)
+			loss = e.ValidationLoss
+		}
+		history = append(history, e)
+
+		stop := false
+		if options.Patience > 0 {
+			if bestEpoch < 0 || loss < bestLoss {
+				bestLoss, bestEpoch = loss, epoch
+				net.mu.RLock()
+				best = copyParameterValues(net.parameters(), best)
+				bestStatistics = net.runningStatistics()
+				net.mu.RUnlock()
+			}
+			stop = epoch-bestEpoch >= options.Patience
+		}
+		for _, callback := range options.Callbacks {
+			if callback(history) {
+				stop = true
+			}
+		}
+		if stop {
+			break
+		}
+	}
+
+	net.restoreBest(best, bestStatistics)
+	return history, nil
+}
+
+// Give the network the parameter values and running statistics of the best
+// epoch of Fit, if any
+func (net *FeedForward) restoreBest(best []Parameters, statistics [][]float64) {
+	if best == nil {
+		return
+	}
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	for i, p := range net.parameters() {
+		copy(p.Values, best[i].Values)
+	}
+	net.restoreRunningStatistics(statistics)
+}
+
+// Run one step of gradient descent on a batch, returning its summed loss and
+// number of correct predictions before the step
+func (net *FeedForward) fitBatch(inputs [][]float64, outputs [][]float64) (float64, float64, error) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+
+	prediction, err := net.train(inputs, outputs)
+	if err != nil {
+		return 0, 0, err
+	}
+	loss, correct := net.evaluate(prediction, outputs)
+	return loss, correct, nil
+}
+
+// The summed loss and number of correct predictions of a batch
+func (net *FeedForward) evaluate(prediction *matrix, outputs [][]float64) (loss float64, correct float64) {
+	for i := 0; i < prediction.rows; i++ {
+		p := prediction.row(i)
+		loss += net.Loss.Loss(p, outputs[i])
+		if len(p) == 1 {
+			if (p[0] >= 0.5) == (outputs[i][0] >= 0.5) {
+				correct++
+			}
+		} else if argmax(p) == argmax(outputs[i]) {
+			correct++
+		}
+	}
+	return loss, correct
+}
+
+func argmax(values []float64) int {
+	largest := 0
+	for i, v := range values {
+		if v > values[largest] {
+			largest = i
+		}
+	}
+	return largest
+}
+
+// Copy the values of params into dst, allocating dst if it is nil
+func copyParameterValues(params []Parameters, dst []Parameters) []Parameters {
+	if dst == nil {
+		dst = make([]Parameters, len(params))
+		for i, p := range params {
+			dst[i].Values = make([]float64, len(p.Values))
+		}
+	}
+	for i, p := range params {
+		copy(dst[i].Values, p.Values)
+	}
+	return dst
+}
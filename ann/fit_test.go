@@ -0,0 +1,142 @@
+package ann
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+	net := NewFeedForwardWithOptions(Options{LearningRate: 0.5, Loss: new(CategoricalCrossEntropy), Source: rand.NewSource(1)},
+		NewInputLayer(2),
+		NewLayer(8, new(TanhActivation)),
+		NewSoftmaxLayer(3),
+	)
+	inputs := [][]float64{{1, 0}, {0.9, 0.1}, {0, 1}, {0.1, 0.9}, {-1, -1}, {-0.9, -1}, {0.8, 0}, {0, 0.8}, {-0.8, -0.8}}
+	outputs := [][]float64{{1, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 1, 0}, {0, 0, 1}, {0, 0, 1}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+
+	var calls int
+	history, err := net.Fit(inputs, outputs, FitOptions{
+		Epochs:          200,
+		BatchSize:       2,
+		ValidationSplit: 1.0 / 3,
+		Callbacks:       []func(History) bool{func(h History) bool { calls++; return false }},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 200 || calls != 200 {
+		t.Fatalf("Expected 200 epochs and callbacks, got %v and %v", len(history), calls)
+	}
+	first, last := history[0], history[len(history)-1]
+	if last.Loss >= first.Loss || last.ValidationLoss >= first.ValidationLoss {
+		t.Errorf("Loss did not decrease from %+v to %+v", first, last)
+	}
+	if last.Accuracy != 1 || last.ValidationAccuracy != 1 {
+		t.Errorf("Expected every example to be classified correctly, got %+v", last)
+	}
+}
+
+func TestFitEarlyStopping(t *testing.T) {
+	net := NewFeedForwardWithOptions(Options{LearningRate: 0.5, Source: rand.NewSource(1)},
+		NewInputLayer(1),
+		NewLayer(1, new(SigmoidActivation)),
+	)
+
+	// Training pulls the output towards 1 while validation wants 0, so the
+	// validation loss is lowest after the first epoch
+	inputs := [][]float64{{1}, {1}, {1}, {1}}
+	outputs := [][]float64{{1}, {1}, {1}, {0}}
+
+	var best []Parameters
+	history, err := net.Fit(inputs, outputs, FitOptions{
+		Epochs:          100,
+		ValidationSplit: 0.25,
+		Patience:        3,
+		Callbacks: []func(History) bool{func(h History) bool {
+			if len(h) == 1 {
+				best = copyParameterValues(net.parameters(), nil)
+			}
+			return false
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 4 {
+		t.Fatalf("Expected training to stop after 4 epochs, got %v", len(history))
+	}
+	for i, p := range net.parameters() {
+		if !reflect.DeepEqual(p.Values, best[i].Values) {
+			t.Fatal("Weights of the best epoch were not restored")
+		}
+	}
+
+	// Callbacks can stop training too
+	history, _ = net.Fit(inputs, outputs, FitOptions{
+		Epochs:    100,
+		Callbacks: []func(History) bool{func(h History) bool { return len(h) == 2 }},
+	})
+	if len(history) != 2 {
+		t.Errorf("Expected a callback to stop training after 2 epochs, got %v", len(history))
+	}
+}
+
+func TestFitErrors(t *testing.T) {
+	net := NewFeedForward(0.1, NewInputLayer(1), NewLayer(1, nil))
+	inputs := [][]float64{{1}, {2}}
+	outputs := [][]float64{{1}, {2}}
+	for _, split := range []float64{-0.1, 1, 1.5} {
+		if _, err := net.Fit(inputs, outputs, FitOptions{ValidationSplit: split}); err != ValidationSplitError {
+			t.Errorf("Expected ValidationSplitError for split %v, got %v", split, err)
+		}
+	}
+	if _, err := net.Fit(inputs, outputs[:1], FitOptions{}); err != BatchSizeMismatchError {
+		t.Errorf("Expected BatchSizeMismatchError, got %v", err)
+	}
+
+	// Outputs held out for validation are checked too
+	net = NewFeedForward(0.1, NewInputLayer(1), NewLayer(2, nil))
+	inputs = [][]float64{{1}, {2}, {3}, {4}}
+	outputs = [][]float64{{1, 0}, {0, 1}, {1, 0}, {1}}
+	if _, err := net.Fit(inputs, outputs, FitOptions{ValidationSplit: 0.25}); err != OutputDimensionMismatchError {
+		t.Errorf("Expected OutputDimensionMismatchError, got %v", err)
+	}
+}
+
+func TestFitEarlyStoppingRestoresRunningStatistics(t *testing.T) {
+	net := NewFeedForwardWithOptions(Options{LearningRate: 0.5, Source: rand.NewSource(1)},
+		NewInputLayer(1),
+		NewLayer(2, nil),
+		NewBatchNormLayer(nil),
+		NewLayer(1, new(SigmoidActivation)),
+	)
+
+	// As above the validation loss is lowest after the first epoch, while the
+	// running statistics keep moving towards those of the training batches
+	inputs := [][]float64{{1}, {2}, {3}, {2}}
+	outputs := [][]float64{{1}, {1}, {1}, {0}}
+
+	var best [][]float64
+	history, err := net.Fit(inputs, outputs, FitOptions{
+		Epochs:          100,
+		BatchSize:       3,
+		ValidationSplit: 0.25,
+		Patience:        3,
+		Callbacks: []func(History) bool{func(h History) bool {
+			if len(h) == 1 {
+				best, _ = net.PredictBatch(inputs)
+			}
+			return false
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 4 {
+		t.Fatalf("Expected training to stop after 4 epochs, got %v", len(history))
+	}
+	if predictions, _ := net.PredictBatch(inputs); !reflect.DeepEqual(predictions, best) {
+		t.Errorf("Expected the predictions of the best epoch %v, got %v", best, predictions)
+	}
+}
@@ -0,0 +1,120 @@
+package ann
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	// Step used for the finite differences of CheckGradients
+	gradientCheckStep = 1e-6
+
+	// Seed of the dropout masks of CheckGradients
+	gradientCheckSeed = 1
+)
+
+// Compare the gradients of the loss of a batch computed by backpropagation,
+// as used by Train, with central finite differences of the loss for every
+// weight and bias of the network. Weight penalties are included in the loss.
+// Returns the largest difference between the two, relative to the size of
+// the gradients when they are larger than 1. Differences above about 1e-6
+// point to a mistake in backpropagation.
+//
+// Dropout layers drop the same outputs on every pass of the check, drawn from
+// a random source of their own. The parameters, running statistics and random
+// source of the network are left unchanged, the gradients of the parameters
+// are overwritten with those of the batch.
+func (net *FeedForward) CheckGradients(inputs [][]float64, outputs [][]float64) (float64, error) {
+	if err := net.lock(); err != nil {
+		return 0, err
+	}
+	defer net.mu.Unlock()
+
+	if len(net.layers) < 2 {
+		return 0, NotInitializedError
+	}
+	defer net.restoreRunningStatistics(net.runningStatistics())
+	defer net.restoreDropoutSources(net.dropoutSources())
+
+	net.resetDropoutSources()
+	if _, err := net.gradients(inputs, outputs); err != nil {
+		return 0, err
+	}
+
+	loss := func() float64 {
+		net.resetDropoutSources()
+		prediction, _ := net.forward(matrixFromRows(inputs), true)
+		var loss float64
+		for i := 0; i < prediction.rows; i++ {
+			loss += net.Loss.Loss(prediction.row(i), outputs[i])
+		}
+		return loss/float64(prediction.rows) + net.penalty()
+	}
+
+	var largest float64
+	for _, p := range net.parameters() {
+		for i, v := range p.Values {
+			p.Values[i] = v + gradientCheckStep
+			up := loss()
+			p.Values[i] = v - gradientCheckStep
+			down := loss()
+			p.Values[i] = v
+
+			numerical := (up - down) / (2 * gradientCheckStep)
+			scale := math.Max(1, math.Max(math.Abs(numerical), math.Abs(p.Gradients[i])))
+			largest = math.Max(largest, math.Abs(numerical-p.Gradients[i])/scale)
+		}
+	}
+	return largest, nil
+}
+
+// The weight penalties of every layer, whose gradients training adds to those of the loss
+func (net *FeedForward) penalty() float64 {
+	var penalty float64
+	for _, l := range net.layers[1:] {
+		var dense *DenseLayer
+		switch l := l.(type) {
+		case *DenseLayer:
+			dense = l
+		case *SoftmaxLayer:
+			dense = &l.DenseLayer
+		default:
+			continue
+		}
+		for _, w := range dense.weights.data {
+			penalty += dense.l1*math.Abs(w) + dense.l2*w*w/2
+		}
+	}
+	return penalty
+}
+
+// The random sources of every dropout layer
+func (net *FeedForward) dropoutSources() []*rand.Rand {
+	var sources []*rand.Rand
+	for _, l := range net.layers[1:] {
+		if l, ok := l.(*DropoutLayer); ok {
+			sources = append(sources, l.rng)
+		}
+	}
+	return sources
+}
+
+func (net *FeedForward) restoreDropoutSources(sources []*rand.Rand) {
+	for _, l := range net.layers[1:] {
+		if l, ok := l.(*DropoutLayer); ok {
+			l.rng = sources[0]
+			sources = sources[1:]
+		}
+	}
+}
+
+// Give every dropout layer a new source with the seed of the gradient check,
+// so that every pass of the check drops the same outputs
+func (net *FeedForward) resetDropoutSources() {
+	rng := rand.New(rand.NewSource(gradientCheckSeed))
+	for _, l := range net.layers[1:] {
+		if l, ok := l.(*DropoutLayer); ok {
+			l.rng = rng
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package ann
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestCheckGradients(t *testing.T) {
+	inputs := [][]float64{{1, 0, 0.5}, {0.2, 0.3, -1}, {-0.5, 1, 0.1}, {0.7, -0.2, 0.4}}
+	outputs := [][]float64{{1, 0}, {0, 1}, {0, 1}, {1, 0}}
+	xavier := LayerOptions{Initializer: new(XavierInitializer)}
+
+	for name, test := range map[string]struct {
+		loss   Loss
+		layers []Layer
+	}{
+		"linear":  {new(MeanSquaredError), []Layer{NewLayer(4, nil), NewLayer(2, nil)}},
+		"sigmoid": {new(BinaryCrossEntropy), []Layer{NewLayer(4, new(SigmoidActivation)), NewLayer(2, new(SigmoidActivation))}},
+		"relu":    {NewHuberLoss(0.5), []Layer{NewLayerWithOptions(4, new(ReLUActivation), xavier), NewLayer(2, nil)}},
+		"tanh":    {new(MeanSquaredError), []Layer{NewLayer(4, new(TanhActivation)), NewLayer(2, new(TanhActivation))}},
+		"softmax": {new(CategoricalCrossEntropy), []Layer{NewLayer(4, new(TanhActivation)), NewSoftmaxLayer(2)}},
+		// Without cross entropy the gradient goes through the softmax jacobian
+		"softmax mse": {new(MeanSquaredError), []Layer{NewLayer(4, new(TanhActivation)), NewSoftmaxLayer(2)}},
+		"dropout":     {new(MeanSquaredError), []Layer{NewLayer(8, new(SigmoidActivation)), NewDropoutLayer(0.5), NewLayer(2, nil)}},
+		"batchnorm":   {new(MeanSquaredError), []Layer{NewLayer(4, nil), NewBatchNormLayer(new(TanhActivation)), NewLayer(2, nil)}},
+		"penalties": {new(MeanSquaredError), []Layer{
+			NewLayerWithOptions(4, new(TanhActivation), LayerOptions{L1: 0.1, L2: 0.1}),
+			NewSoftmaxLayerWithOptions(2, LayerOptions{L2: 0.5}),
+		}},
+	} {
+		net := NewFeedForwardWithOptions(Options{LearningRate: 0.1, Loss: test.loss, Source: rand.NewSource(1)},
+			append([]Layer{NewInputLayer(3)}, test.layers...)...,
+		)
+		before := copyParameterValues(net.parameters(), nil)
+		largest, err := net.CheckGradients(inputs, outputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if largest > 1e-6 {
+			t.Errorf("%v: analytical gradients differ from numerical gradients by %v", name, largest)
+		}
+		for i, p := range net.parameters() {
+			if !reflect.DeepEqual(p.Values, before[i].Values) {
+				t.Errorf("%v: checking gradients changed the parameters", name)
+			}
+		}
+	}
+}
+
+func TestCheckGradientsKeepsRandomSource(t *testing.T) {
+	newNet := func() FeedForward {
+		return NewFeedForwardWithOptions(Options{LearningRate: 0.1, Source: rand.NewSource(1)},
+			NewInputLayer(64),
+			NewLayer(16, new(SigmoidActivation)),
+			NewDropoutLayer(0.5),
+			NewLayer(10, new(SigmoidActivation)),
+		)
+	}
+	checked, unchecked := newNet(), newNet()
+	inputs, outputs := randomDigits(4)
+	if _, err := checked.CheckGradients(inputs, outputs); err != nil {
+		t.Fatal(err)
+	}
+
+	// Later training drops the same outputs as if the check had not run
+	for _, net := range []*FeedForward{&checked, &unchecked} {
+		if _, err := net.TrainBatch(inputs, outputs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(checked.parameters(), unchecked.parameters()) {
+		t.Error("Checking gradients changed how the network trains")
+	}
+}
+
+// A sigmoid with the derivative of tanh
+type wrongDerivativeActivation struct{ SigmoidActivation }
+
+func (a *wrongDerivativeActivation) CalcDerivative(x float64) float64 {
+	return new(TanhActivation).CalcDerivative(x)
+}
+
+func TestCheckGradientsFindsMistakes(t *testing.T) {
+	net := NewFeedForwardWithOptions(Options{LearningRate: 0.1, Source: rand.NewSource(1)},
+		NewInputLayer(2),
+		NewLayer(2, new(wrongDerivativeActivation)),
+	)
+	largest, err := net.CheckGradients([][]float64{{1, 2}}, [][]float64{{0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if largest < 1e-3 {
+		t.Errorf("Expected a wrong derivative to be found, largest difference was %v", largest)
+	}
+
+	if _, err := new(FeedForward).CheckGradients([][]float64{{1}}, [][]float64{{1}}); err != NotInitializedError {
+		t.Errorf("Expected NotInitializedError, got %v", err)
+	}
+}
+
+func TestActivationDerivatives(t *testing.T) {
+	for name, activation := range map[string]ActivationFunction{
+		"sigmoid": new(SigmoidActivation),
+		"relu":    new(ReLUActivation),
+		"tanh":    new(TanhActivation),
+	} {
+		for _, x := range []float64{-2, -0.5, 0, 0.5, 2} {
+			d := activation.CalcDerivative(x)
+			if math.IsNaN(d) {
+				t.Errorf("%v derivative at %v is NaN", name, x)
+				continue
+			}
+			// ReLU has no derivative at 0, any value between its one sided derivatives will do
+			if name == "relu" && x == 0 {
+				if d < 0 || d > 1 {
+					t.Errorf("relu derivative at 0 is %v", d)
+				}
+				continue
+			}
+			const h = 1e-6
+			if numerical := (activation.Calc(x+h) - activation.Calc(x-h)) / (2 * h); math.Abs(numerical-d) > 1e-6 {
+				t.Errorf("%v derivative at %v is %v, expected %v", name, x, d, numerical)
+			}
+		}
+	}
+}
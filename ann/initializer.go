@@ -0,0 +1,83 @@
+package ann
+
+import (
+	"math"
+	"math/rand"
+)
+
+type (
+	// An Initializer sets the starting weights and biases of a layer with
+	// fanIn inputs and fanOut neurons
+	Initializer interface {
+		Initialize(weights, biases []float64, fanIn, fanOut int, rng *rand.Rand)
+	}
+
+	// Standard normal weights and biases drawn uniformly from (0, 1), the
+	// initialization FeedForward has always used. Suits small networks only,
+	// as the signals of larger layers start out saturated.
+	NormalInitializer struct{}
+
+	// Weights drawn uniformly from ±sqrt(6 / (fanIn + fanOut)) and zero biases,
+	// which keeps the variance of signals and gradients constant across sigmoid
+	// and tanh layers. See Glorot and Bengio "Understanding the difficulty of
+	// training deep feedforward neural networks", 2010.
+	XavierInitializer struct{}
+
+	// Normal weights with a standard deviation of sqrt(2 / fanIn) and zero
+	// biases, the equivalent of Xavier initialization for ReLU layers. See He
+	// et al. "Delving deep into rectifiers", 2015.
+	HeInitializer struct{}
+
+	// Weights drawn uniformly from ±Limit and zero biases
+	UniformInitializer struct {
+		Limit float64
+	}
+)
+
+func (i *NormalInitializer) Initialize(weights, biases []float64, fanIn, fanOut int, rng *rand.Rand) {
+	for j := range weights {
+		for weights[j] == 0 {
+			weights[j] = rng.NormFloat64()
+		}
+	}
+	for j := range biases {
+		for biases[j] == 0 {
+			biases[j] = rng.Float64()
+		}
+	}
+}
+
+func (i *XavierInitializer) Initialize(weights, biases []float64, fanIn, fanOut int, rng *rand.Rand) {
+	uniform(weights, math.Sqrt(6/float64(fanIn+fanOut)), rng)
+	zero(biases)
+}
+
+func (i *HeInitializer) Initialize(weights, biases []float64, fanIn, fanOut int, rng *rand.Rand) {
+	sigma := math.Sqrt(2 / float64(fanIn))
+	for j := range weights {
+		weights[j] = sigma * rng.NormFloat64()
+	}
+	zero(biases)
+}
+
+func NewUniformInitializer(limit float64) *UniformInitializer {
+	return &UniformInitializer{Limit: limit}
+}
+
+func (i *UniformInitializer) Initialize(weights, biases []float64, fanIn, fanOut int, rng *rand.Rand) {
+	uniform(weights, i.Limit, rng)
+	zero(biases)
+}
+
+// Fill values uniformly from (-limit, limit)
+func uniform(values []float64, limit float64, rng *rand.Rand) {
+	for j := range values {
+		values[j] = (2*rng.Float64() - 1) * limit
+	}
+}
+
+func zero(values []float64) {
+	for j := range values {
+		values[j] = 0
+	}
+}
@@ -0,0 +1,82 @@
+package ann
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSeededNetworksAreReproducible(t *testing.T) {
+	newNet := func(seed int64) FeedForward {
+		return NewFeedForwardWithOptions(Options{LearningRate: 0.1, Source: rand.NewSource(seed)},
+			NewInputLayer(64),
+			NewLayerWithOptions(32, new(TanhActivation), LayerOptions{Initializer: new(XavierInitializer)}),
+			NewLayer(10, new(SigmoidActivation)),
+		)
+	}
+	a, b, c := newNet(1), newNet(1), newNet(2)
+	if !reflect.DeepEqual(a.parameters(), b.parameters()) {
+		t.Fatal("Networks with the same seed start with different weights")
+	}
+	if reflect.DeepEqual(a.parameters(), c.parameters()) {
+		t.Fatal("Networks with different seeds start with the same weights")
+	}
+
+	inputs, outputs := randomDigits(16)
+	for _, net := range []*FeedForward{&a, &b} {
+		for i := 0; i < 10; i++ {
+			if _, err := net.TrainBatch(inputs, outputs); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	predictionsA, _ := a.PredictBatch(inputs)
+	predictionsB, _ := b.PredictBatch(inputs)
+	if !reflect.DeepEqual(predictionsA, predictionsB) {
+		t.Error("Networks with the same seed trained differently")
+	}
+}
+
+func TestInitializers(t *testing.T) {
+	const fanIn, fanOut = 200, 100
+	rng := rand.New(rand.NewSource(1))
+
+	for name, test := range map[string]struct {
+		initializer Initializer
+		limit       float64
+		stddev      float64
+	}{
+		"xavier":  {new(XavierInitializer), math.Sqrt(6.0 / (fanIn + fanOut)), math.Sqrt(2.0 / (fanIn + fanOut))},
+		"he":      {new(HeInitializer), math.Inf(1), math.Sqrt(2.0 / fanIn)},
+		"uniform": {NewUniformInitializer(0.5), 0.5, 0.5 / math.Sqrt(3)},
+	} {
+		weights := make([]float64, fanIn*fanOut)
+		biases := []float64{1, 2}
+		test.initializer.Initialize(weights, biases, fanIn, fanOut, rng)
+
+		var sumSq float64
+		for _, w := range weights {
+			if math.Abs(w) > test.limit {
+				t.Errorf("%v weight %v outside of ±%v", name, w, test.limit)
+				break
+			}
+			sumSq += w * w
+		}
+		if stddev := math.Sqrt(sumSq / float64(len(weights))); math.Abs(stddev-test.stddev) > 0.05*test.stddev {
+			t.Errorf("%v weights have standard deviation %v, expected %v", name, stddev, test.stddev)
+		}
+		if biases[0] != 0 || biases[1] != 0 {
+			t.Errorf("%v did not zero biases: %v", name, biases)
+		}
+	}
+
+	weights := make([]float64, 100)
+	biases := make([]float64, 10)
+	new(NormalInitializer).Initialize(weights, biases, 10, 10, rng)
+	for _, b := range biases {
+		if b <= 0 || b >= 1 {
+			t.Errorf("Normal initializer bias %v outside of (0, 1)", b)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package ann
+
+import (
+	"math"
+	"math/rand"
+)
+
+type (
+	// A Layer of a FeedForward network. The first layer passed to
+	// NewFeedForward only sets the number of inputs, every following layer
+	// transforms the outputs of the layer before it.
+	Layer interface {
+		// The number of outputs of the layer
+		Size() int
+
+		// Allocate and initialize parameters for the given number of inputs,
+		// drawing any random values from rng
+		connect(inputs int, rng *rand.Rand)
+
+		// Transform a batch of inputs, one example per row. Layers such as
+		// dropout only act while training. The returned cache holds whatever
+		// backward needs and is owned by the caller so that layers keep no
+		// state between passes. Passes which are not training return no cache
+		// and must not modify the layer, so that any number can run at once.
+		forward(in *matrix, training bool) (out *matrix, cache interface{})
+
+		// Given the gradient of the loss with respect to the outputs of a
+		// forward pass, set the gradients of the parameters and return the
+		// gradient with respect to the inputs. The first layer after the input
+		// layer is not asked for the input gradient as nothing uses it.
+		backward(cache interface{}, gradOut *matrix, needGradIn bool) *matrix
+
+		// The trainable parameters of the layer
+		parameters() []Parameters
+
+		// The saved form of the layer
+		save() (layerModel, error)
+	}
+
+	// DenseLayer connects each of its neurons to every output of the previous
+	// layer. A neuron outputs its activation function applied to the weighted
+	// sum of its inputs plus a bias.
+	DenseLayer struct {
+		size           int
+		activationFunc ActivationFunction
+		initializer    Initializer
+		l1, l2         float64
+
+		// Weights from each input to each neuron, one row per neuron
+		weights         *matrix
+		biases          []float64
+		weightGradients *matrix
+		biasGradients   []float64
+	}
+
+	// The values of a forward pass through a DenseLayer needed to backpropagate
+	denseCache struct {
+		in     *matrix
+		signal *matrix
+	}
+
+	// SoftmaxLayer is a fully connected layer whose outputs are the softmax of
+	// the weighted sums of its neurons, a probability distribution over its
+	// outputs. Unlike an activation function softmax depends on every neuron
+	// of the layer, so it is a layer of its own. It is meant as the output
+	// layer of a classifier trained with CategoricalCrossEntropy.
+	SoftmaxLayer struct {
+		DenseLayer
+	}
+
+	softmaxCache struct {
+		dense *denseCache
+		out   *matrix
+	}
+
+	// LayerOptions configure a DenseLayer or SoftmaxLayer beyond its size
+	LayerOptions struct {
+		// Sets the starting weights, nil uses a NormalInitializer
+		Initializer Initializer
+
+		// Strength of the L1 and L2 penalties on the weights of the layer.
+		// Training adds L1 * sign(w) + L2 * w to the gradient of each weight,
+		// pulling weights towards 0 to reduce overfitting. Biases are not
+		// penalized.
+		L1, L2 float64
+	}
+)
+
+// A layer of fully connected neurons. A nil activation function outputs the
+// weighted sum unchanged.
+func NewLayer(numNeurons int, activationFunc ActivationFunction) *DenseLayer {
+	return NewLayerWithOptions(numNeurons, activationFunc, LayerOptions{})
+}
+
+func NewLayerWithOptions(numNeurons int, activationFunc ActivationFunction, options LayerOptions) *DenseLayer {
+	l := &DenseLayer{
+		size:           numNeurons,
+		activationFunc: activationFunc,
+		initializer:    options.Initializer,
+		l1:             options.L1,
+		l2:             options.L2,
+	}
+	if l.initializer == nil {
+		l.initializer = new(NormalInitializer)
+	}
+	return l
+}
+
+// The first layer of a network, it only sets the number of inputs
+func NewInputLayer(numNeurons int) *DenseLayer {
+	return NewLayer(numNeurons, nil)
+}
+
+func (l *DenseLayer) Size() int {
+	return l.size
+}
+
+func (l *DenseLayer) connect(inputs int, rng *rand.Rand) {
+	l.weights = newMatrix(l.size, inputs)
+	l.weightGradients = newMatrix(l.size, inputs)
+	l.biases = make([]float64, l.size)
+	l.biasGradients = make([]float64, l.size)
+	l.initializer.Initialize(l.weights.data, l.biases, inputs, l.size, rng)
+}
+
+func (l *DenseLayer) forward(in *matrix, training bool) (*matrix, interface{}) {
+	signal := newMatrix(in.rows, l.size)
+	mulTransB(signal, in, l.weights)
+
+	// Only backpropagation needs the signal, predictions activate it in place
+	out := signal
+	if training {
+		out = newMatrix(in.rows, l.size)
+	}
+	for i := 0; i < signal.rows; i++ {
+		s := signal.row(i)
+		o := out.row(i)
+		for j := range s {
+			s[j] += l.biases[j]
+			o[j] = l.activate(s[j])
+		}
+	}
+	if !training {
+		return out, nil
+	}
+	return out, &denseCache{in: in, signal: signal}
+}
+
+func (l *DenseLayer) backward(c interface{}, gradOut *matrix, needGradIn bool) *matrix {
+	cache := c.(*denseCache)
+
+	// Gradient with respect to the signal of each neuron
+	delta := newMatrix(gradOut.rows, gradOut.cols)
+	for i, g := range gradOut.data {
+		delta.data[i] = g * l.derivative(cache.signal.data[i])
+	}
+
+	mulTransA(l.weightGradients, delta, cache.in)
+	if l.l1 != 0 || l.l2 != 0 {
+		for i, w := range l.weights.data {
+			l.weightGradients.data[i] += l.l1*sign(w) + l.l2*w
+		}
+	}
+	for j := range l.biasGradients {
+		l.biasGradients[j] = 0
+	}
+	for i := 0; i < delta.rows; i++ {
+		for j, d := range delta.row(i) {
+			l.biasGradients[j] += d
+		}
+	}
+
+	if !needGradIn {
+		return nil
+	}
+	gradIn := newMatrix(delta.rows, l.weights.cols)
+	mul(gradIn, delta, l.weights)
+	return gradIn
+}
+
+func (l *DenseLayer) parameters() []Parameters {
+	return []Parameters{
+		{Values: l.weights.data, Gradients: l.weightGradients.data},
+		{Values: l.biases, Gradients: l.biasGradients},
+	}
+}
+
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
+func (l *DenseLayer) activate(x float64) float64 {
+	if l.activationFunc == nil {
+		return x
+	}
+	return l.activationFunc.Calc(x)
+}
+
+func (l *DenseLayer) derivative(x float64) float64 {
+	if l.activationFunc == nil {
+		return 1
+	}
+	return l.activationFunc.CalcDerivative(x)
+}
+
+// A fully connected output layer producing a probability for each of its neurons
+func NewSoftmaxLayer(numNeurons int) *SoftmaxLayer {
+	return NewSoftmaxLayerWithOptions(numNeurons, LayerOptions{})
+}
+
+func NewSoftmaxLayerWithOptions(numNeurons int, options LayerOptions) *SoftmaxLayer {
+	return &SoftmaxLayer{*NewLayerWithOptions(numNeurons, nil, options)}
+}
+
+func (l *SoftmaxLayer) forward(in *matrix, training bool) (*matrix, interface{}) {
+	signal, dense := l.DenseLayer.forward(in, training)
+
+	out := signal
+	if training {
+		out = newMatrix(signal.rows, signal.cols)
+	}
+	for i := 0; i < out.rows; i++ {
+		// Shift by the largest signal so that exp cannot overflow
+		s := signal.row(i)
+		max := math.Inf(-1)
+		for _, x := range s {
+			max = math.Max(max, x)
+		}
+		var sum float64
+		o := out.row(i)
+		for j, x := range s {
+			o[j] = math.Exp(x - max)
+			sum += o[j]
+		}
+		for j := range o {
+			o[j] /= sum
+		}
+	}
+	if !training {
+		return out, nil
+	}
+	return out, &softmaxCache{dense: dense.(*denseCache), out: out}
+}
+
+func (l *SoftmaxLayer) backward(c interface{}, gradOut *matrix, needGradIn bool) *matrix {
+	cache := c.(*softmaxCache)
+
+	// Multiply by the jacobian of softmax, d out_j / d signal_k = out_j * (δ_jk - out_k)
+	gradSignal := newMatrix(gradOut.rows, gradOut.cols)
+	for i := 0; i < gradOut.rows; i++ {
+		out := cache.out.row(i)
+		g := gradOut.row(i)
+		var dot float64
+		for j, o := range out {
+			dot += g[j] * o
+		}
+		for j, o := range out {
+			gradSignal.row(i)[j] = o * (g[j] - dot)
+		}
+	}
+	return l.backwardSignal(cache, gradSignal, needGradIn)
+}
+
+// Backpropagate a gradient with respect to the weighted sums, skipping softmax
+func (l *SoftmaxLayer) backwardSignal(c interface{}, gradSignal *matrix, needGradIn bool) *matrix {
+	return l.DenseLayer.backward(c.(*softmaxCache).dense, gradSignal, needGradIn)
+}
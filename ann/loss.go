@@ -0,0 +1,110 @@
+package ann
+
+import "math"
+
+type (
+	// A Loss measures how far the prediction of a network for a single example
+	// is from its target. Training minimizes the loss averaged over each batch.
+	Loss interface {
+		Loss(prediction, target []float64) float64
+
+		// Set grad to the derivative of the loss with respect to each prediction
+		Gradient(prediction, target, grad []float64)
+	}
+
+	// Half of the summed squared error of the outputs, the loss FeedForward
+	// has always been trained with. Its gradient is the difference between
+	// prediction and target.
+	MeanSquaredError struct{}
+
+	// Cross entropy of independent yes or no outputs, for sigmoid outputs
+	// predicting targets of 0 or 1
+	BinaryCrossEntropy struct{}
+
+	// Cross entropy of a probability distribution over classes, for a
+	// softmax output layer predicting one hot targets
+	CategoricalCrossEntropy struct{}
+
+	// Squared error for small differences and absolute error for large ones,
+	// which makes regression less sensitive to outliers
+	HuberLoss struct {
+		// The difference at which the loss turns from quadratic to linear
+		Delta float64
+	}
+)
+
+// Probabilities are kept this far from 0 and 1 so that the cross entropies stay finite
+const probabilityEpsilon = 1e-12
+
+func (l *MeanSquaredError) Loss(prediction, target []float64) float64 {
+	var loss float64
+	for i, p := range prediction {
+		loss += (p - target[i]) * (p - target[i]) / 2
+	}
+	return loss
+}
+
+func (l *MeanSquaredError) Gradient(prediction, target, grad []float64) {
+	for i, p := range prediction {
+		grad[i] = p - target[i]
+	}
+}
+
+func (l *BinaryCrossEntropy) Loss(prediction, target []float64) float64 {
+	var loss float64
+	for i, p := range prediction {
+		p = clipProbability(p)
+		loss -= target[i]*math.Log(p) + (1-target[i])*math.Log(1-p)
+	}
+	return loss
+}
+
+func (l *BinaryCrossEntropy) Gradient(prediction, target, grad []float64) {
+	for i, p := range prediction {
+		p = clipProbability(p)
+		grad[i] = (p - target[i]) / (p * (1 - p))
+	}
+}
+
+func (l *CategoricalCrossEntropy) Loss(prediction, target []float64) float64 {
+	var loss float64
+	for i, p := range prediction {
+		if target[i] != 0 {
+			loss -= target[i] * math.Log(clipProbability(p))
+		}
+	}
+	return loss
+}
+
+func (l *CategoricalCrossEntropy) Gradient(prediction, target, grad []float64) {
+	for i, p := range prediction {
+		grad[i] = -target[i] / clipProbability(p)
+	}
+}
+
+func NewHuberLoss(delta float64) *HuberLoss {
+	return &HuberLoss{Delta: delta}
+}
+
+func (l *HuberLoss) Loss(prediction, target []float64) float64 {
+	var loss float64
+	for i, p := range prediction {
+		d := math.Abs(p - target[i])
+		if d <= l.Delta {
+			loss += d * d / 2
+		} else {
+			loss += l.Delta * (d - l.Delta/2)
+		}
+	}
+	return loss
+}
+
+func (l *HuberLoss) Gradient(prediction, target, grad []float64) {
+	for i, p := range prediction {
+		grad[i] = math.Max(-l.Delta, math.Min(l.Delta, p-target[i]))
+	}
+}
+
+func clipProbability(p float64) float64 {
+	return math.Max(probabilityEpsilon, math.Min(1-probabilityEpsilon, p))
+}
@@ -0,0 +1,115 @@
+package ann
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestLossGradients(t *testing.T) {
+	prediction := []float64{0.2, 0.7, 0.1}
+	target := []float64{0, 1, 0}
+	for name, loss := range map[string]Loss{
+		"mse":         new(MeanSquaredError),
+		"binary":      new(BinaryCrossEntropy),
+		"categorical": new(CategoricalCrossEntropy),
+		"huber":       NewHuberLoss(0.25),
+	} {
+		grad := make([]float64, len(prediction))
+		loss.Gradient(prediction, target, grad)
+
+		// Compare with central differences
+		for i := range prediction {
+			const h = 1e-6
+			p := append([]float64(nil), prediction...)
+			p[i] += h
+			up := loss.Loss(p, target)
+			p[i] -= 2 * h
+			down := loss.Loss(p, target)
+			if numerical := (up - down) / (2 * h); math.Abs(numerical-grad[i]) > 1e-5 {
+				t.Errorf("%v gradient %v: analytical %v, numerical %v", name, i, grad[i], numerical)
+			}
+		}
+	}
+}
+
+func TestLossValues(t *testing.T) {
+	if l := new(CategoricalCrossEntropy).Loss([]float64{0.25, 0.75}, []float64{0, 1}); math.Abs(l+math.Log(0.75)) > 1e-12 {
+		t.Errorf("Unexpected categorical cross entropy %v", l)
+	}
+	if l := new(BinaryCrossEntropy).Loss([]float64{0, 1}, []float64{1, 0}); math.IsInf(l, 0) || math.IsNaN(l) {
+		t.Errorf("Expected binary cross entropy of certain mistakes to stay finite, got %v", l)
+	}
+	// Quadratic up to delta and linear beyond it
+	if l := NewHuberLoss(1).Loss([]float64{0.5, 3}, []float64{0, 0}); math.Abs(l-(0.125+2.5)) > 1e-12 {
+		t.Errorf("Unexpected huber loss %v", l)
+	}
+}
+
+func TestSoftmaxLayer(t *testing.T) {
+	layer := NewSoftmaxLayer(3)
+	layer.connect(2, rand.New(rand.NewSource(1)))
+
+	// Large signals must not overflow
+	out, _ := layer.forward(matrixFromRows([][]float64{{300, 400}}), false)
+	for _, p := range out.data {
+		if math.IsNaN(p) {
+			t.Fatalf("Softmax overflowed: %v", out.data)
+		}
+	}
+
+	out, cache := layer.forward(matrixFromRows([][]float64{{1, -2}, {0.5, 0.3}}), true)
+	for i := 0; i < out.rows; i++ {
+		var sum float64
+		for _, p := range out.row(i) {
+			sum += p
+		}
+		if math.Abs(sum-1) > 1e-12 {
+			t.Errorf("Softmax outputs %v do not sum to 1", out.row(i))
+		}
+	}
+
+	// The joint gradient of cross entropy and softmax matches the chain rule
+	targets := [][]float64{{0, 1, 0}, {1, 0, 0}}
+	chain := newMatrix(out.rows, out.cols)
+	joint := newMatrix(out.rows, out.cols)
+	for i := 0; i < out.rows; i++ {
+		new(CategoricalCrossEntropy).Gradient(out.row(i), targets[i], chain.row(i))
+		for j, p := range out.row(i) {
+			joint.row(i)[j] = p - targets[i][j]
+		}
+	}
+	a := layer.backward(cache, chain, true)
+	b := layer.backwardSignal(cache, joint, true)
+	for i := range a.data {
+		if math.Abs(a.data[i]-b.data[i]) > 1e-6 {
+			t.Fatalf("Joint gradient %v differs from chain rule %v", b.data, a.data)
+		}
+	}
+}
+
+func TestSoftmaxClassifier(t *testing.T) {
+	net := NewFeedForwardWithOptions(
+		Options{LearningRate: 0.5, Loss: new(CategoricalCrossEntropy)},
+		NewInputLayer(2),
+		NewLayer(8, new(TanhActivation)),
+		NewSoftmaxLayer(3),
+	)
+	inputs := [][]float64{{1, 0}, {0.9, 0.1}, {0, 1}, {0.1, 0.9}, {-1, -1}, {-0.9, -1}}
+	outputs := [][]float64{{1, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 1, 0}, {0, 0, 1}, {0, 0, 1}}
+
+	for i := 0; i < 500; i++ {
+		if _, err := net.TrainBatch(inputs, outputs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	predictions, _ := net.PredictBatch(inputs)
+	for i, p := range predictions {
+		for j := range p {
+			if outputs[i][j] == 1 && p[j] < 0.9 {
+				t.Errorf("Expected input %v to be class %v with high probability, got %v", inputs[i], j, p)
+			}
+		}
+	}
+}
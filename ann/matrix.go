@@ -0,0 +1,124 @@
+package ann
+
+// A dense row major matrix. Batches are stored with one example per row.
+type matrix struct {
+	rows int
+	cols int
+	data []float64
+}
+
+func newMatrix(rows, cols int) *matrix {
+	return &matrix{rows: rows, cols: cols, data: make([]float64, rows*cols)}
+}
+
+// A matrix with one row per slice, the slices must all have the same length
+func matrixFromRows(rows [][]float64) *matrix {
+	m := newMatrix(len(rows), len(rows[0]))
+	for i, row := range rows {
+		copy(m.row(i), row)
+	}
+	return m
+}
+
+func (m *matrix) row(i int) []float64 {
+	return m.data[i*m.cols : (i+1)*m.cols]
+}
+
+// The rows of the matrix as separate slices
+func (m *matrix) toRows() [][]float64 {
+	rows := make([][]float64, m.rows)
+	for i := range rows {
+		rows[i] = append([]float64(nil), m.row(i)...)
+	}
+	return rows
+}
+
+// out = a * b
+func mul(out, a, b *matrix) {
+	for i := range out.data {
+		out.data[i] = 0
+	}
+	for i := 0; i < a.rows; i++ {
+		outRow := out.row(i)
+		for k, x := range a.row(i) {
+			if x == 0 {
+				continue
+			}
+			axpy(x, b.row(k), outRow)
+		}
+	}
+}
+
+// out = a * transpose(b)
+func mulTransB(out, a, b *matrix) {
+	for i := 0; i < a.rows; i++ {
+		aRow := a.row(i)
+		outRow := out.row(i)
+		for j := 0; j < b.rows; j++ {
+			outRow[j] = dot(aRow, b.row(j))
+		}
+	}
+}
+
+// out = transpose(a) * b. Small batches sum the outer products of their rows,
+// larger batches transpose both matrices so that the sums become dot products.
+func mulTransA(out, a, b *matrix) {
+	if a.rows >= 8 {
+		mulTransB(out, a.transpose(), b.transpose())
+		return
+	}
+
+	for i := range out.data {
+		out.data[i] = 0
+	}
+	for k := 0; k < a.rows; k++ {
+		bRow := b.row(k)
+		for i, x := range a.row(k) {
+			if x == 0 {
+				continue
+			}
+			axpy(x, bRow, out.row(i))
+		}
+	}
+}
+
+func (m *matrix) transpose() *matrix {
+	t := newMatrix(m.cols, m.rows)
+	for i := 0; i < m.rows; i++ {
+		for j, x := range m.row(i) {
+			t.data[j*t.cols+i] = x
+		}
+	}
+	return t
+}
+
+// y += a * x, unrolled as the inner loop of every matrix product
+func axpy(a float64, x, y []float64) {
+	y = y[:len(x)]
+	i := 0
+	for ; i+4 <= len(x); i += 4 {
+		y[i] += a * x[i]
+		y[i+1] += a * x[i+1]
+		y[i+2] += a * x[i+2]
+		y[i+3] += a * x[i+3]
+	}
+	for ; i < len(x); i++ {
+		y[i] += a * x[i]
+	}
+}
+
+func dot(x, y []float64) float64 {
+	y = y[:len(x)]
+	var s0, s1, s2, s3 float64
+	i := 0
+	for ; i+4 <= len(x); i += 4 {
+		s0 += x[i] * y[i]
+		s1 += x[i+1] * y[i+1]
+		s2 += x[i+2] * y[i+2]
+		s3 += x[i+3] * y[i+3]
+	}
+	for ; i < len(x); i++ {
+		s0 += x[i] * y[i]
+	}
+	return s0 + s1 + s2 + s3
+}
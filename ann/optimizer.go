@@ -0,0 +1,167 @@
+package ann
+
+import "math"
+
+type (
+	// A block of trainable values of a layer and the gradient of the loss with
+	// respect to each of them, averaged over the last batch
+	Parameters struct {
+		Values    []float64
+		Gradients []float64
+	}
+
+	// An Optimizer moves the parameters of a network against their gradients
+	// after every batch. The same blocks are passed in the same order on
+	// every call, so optimizers can keep state for each value by position.
+	Optimizer interface {
+		Update(learningRate float64, params []Parameters)
+	}
+
+	// Plain gradient descent, each value moves by the learning rate times its gradient
+	SGD struct{}
+
+	// Gradient descent with momentum, where each value moves by a velocity
+	// which accumulates its past gradients
+	Momentum struct {
+		// Fraction of the velocity kept between batches
+		Momentum float64
+
+		// Look ahead along the velocity before applying the gradient, as
+		// described in Sutskever et al. "On the importance of initialization
+		// and momentum in deep learning", 2013
+		Nesterov bool
+
+		velocity [][]float64
+	}
+
+	// Adagrad scales the learning rate of each value by the inverse root of
+	// the sum of its squared gradients, so rarely updated values move faster
+	Adagrad struct {
+		// Added to the denominator to avoid dividing by 0
+		Epsilon float64
+
+		sumSquares [][]float64
+	}
+
+	// RMSProp scales the learning rate of each value by the inverse root of
+	// a decaying average of its squared gradients
+	RMSProp struct {
+		// Fraction of the average of squared gradients kept between batches
+		Decay float64
+
+		// Added to the denominator to avoid dividing by 0
+		Epsilon float64
+
+		meanSquares [][]float64
+	}
+
+	// Adam combines momentum with RMSProp style scaling, correcting both
+	// averages for their bias towards 0 in early batches. See Kingma and Ba
+	// "Adam: A method for stochastic optimization", 2014.
+	Adam struct {
+		// Decay of the averages of the gradients and of the squared gradients
+		Beta1 float64
+		Beta2 float64
+
+		// Added to the denominator to avoid dividing by 0
+		Epsilon float64
+
+		steps       int
+		means       [][]float64
+		meanSquares [][]float64
+	}
+)
+
+func (o *SGD) Update(learningRate float64, params []Parameters) {
+	for _, p := range params {
+		for i, g := range p.Gradients {
+			p.Values[i] -= learningRate * g
+		}
+	}
+}
+
+// Momentum, optionally using Nesterov's accelerated gradient. 0.9 is a common momentum.
+func NewMomentum(momentum float64, nesterov bool) *Momentum {
+	return &Momentum{Momentum: momentum, Nesterov: nesterov}
+}
+
+func (o *Momentum) Update(learningRate float64, params []Parameters) {
+	o.velocity = optimizerState(o.velocity, params)
+	for j, p := range params {
+		v := o.velocity[j]
+		for i, g := range p.Gradients {
+			previous := v[i]
+			v[i] = o.Momentum*v[i] - learningRate*g
+			if o.Nesterov {
+				p.Values[i] += -o.Momentum*previous + (1+o.Momentum)*v[i]
+			} else {
+				p.Values[i] += v[i]
+			}
+		}
+	}
+}
+
+func NewAdagrad() *Adagrad {
+	return &Adagrad{Epsilon: 1e-8}
+}
+
+func (o *Adagrad) Update(learningRate float64, params []Parameters) {
+	o.sumSquares = optimizerState(o.sumSquares, params)
+	for j, p := range params {
+		s := o.sumSquares[j]
+		for i, g := range p.Gradients {
+			s[i] += g * g
+			p.Values[i] -= learningRate * g / (math.Sqrt(s[i]) + o.Epsilon)
+		}
+	}
+}
+
+func NewRMSProp() *RMSProp {
+	return &RMSProp{Decay: 0.9, Epsilon: 1e-8}
+}
+
+func (o *RMSProp) Update(learningRate float64, params []Parameters) {
+	o.meanSquares = optimizerState(o.meanSquares, params)
+	for j, p := range params {
+		s := o.meanSquares[j]
+		for i, g := range p.Gradients {
+			s[i] = o.Decay*s[i] + (1-o.Decay)*g*g
+			p.Values[i] -= learningRate * g / (math.Sqrt(s[i]) + o.Epsilon)
+		}
+	}
+}
+
+// Adam with the defaults recommended by its authors, a learning rate of 0.001 works well for most networks
+func NewAdam() *Adam {
+	return &Adam{Beta1: 0.9, Beta2: 0.999, Epsilon: 1e-8}
+}
+
+func (o *Adam) Update(learningRate float64, params []Parameters) {
+	o.means = optimizerState(o.means, params)
+	o.meanSquares = optimizerState(o.meanSquares, params)
+	o.steps++
+
+	// Fold the bias corrections into the step size
+	correction := math.Sqrt(1-math.Pow(o.Beta2, float64(o.steps))) / (1 - math.Pow(o.Beta1, float64(o.steps)))
+	for j, p := range params {
+		m := o.means[j]
+		s := o.meanSquares[j]
+		for i, g := range p.Gradients {
+			m[i] = o.Beta1*m[i] + (1-o.Beta1)*g
+			s[i] = o.Beta2*s[i] + (1-o.Beta2)*g*g
+			p.Values[i] -= learningRate * correction * m[i] / (math.Sqrt(s[i]) + o.Epsilon)
+		}
+	}
+}
+
+// Per value optimizer state, allocated on the first update
+func optimizerState(state [][]float64, params []Parameters) [][]float64 {
+	if state != nil {
+		return state
+	}
+	state = make([][]float64, len(params))
+	for j, p := range params {
+		state[j] = make([]float64, len(p.Values))
+	}
+	return state
+}
@@ -0,0 +1,79 @@
+package ann
+
+import (
+	"math"
+	"testing"
+)
+
+// Minimize the sum of (x - 3)^2 over a few values starting from different points
+func minimize(o Optimizer, learningRate float64, steps int) []float64 {
+	p := Parameters{Values: []float64{-2, 0, 10}, Gradients: make([]float64, 3)}
+	for s := 0; s < steps; s++ {
+		for i, x := range p.Values {
+			p.Gradients[i] = 2 * (x - 3)
+		}
+		o.Update(learningRate, []Parameters{p})
+	}
+	return p.Values
+}
+
+func TestOptimizersMinimizeQuadratic(t *testing.T) {
+	for name, test := range map[string]struct {
+		optimizer    Optimizer
+		learningRate float64
+	}{
+		"sgd":      {new(SGD), 0.1},
+		"momentum": {NewMomentum(0.9, false), 0.01},
+		"nesterov": {NewMomentum(0.9, true), 0.01},
+		"adagrad":  {NewAdagrad(), 1},
+		"rmsprop":  {NewRMSProp(), 0.01},
+		"adam":     {NewAdam(), 0.1},
+	} {
+		for _, x := range minimize(test.optimizer, test.learningRate, 1000) {
+			if math.Abs(x-3) > 1e-2 {
+				t.Errorf("%v did not converge: %v", name, x)
+			}
+		}
+	}
+}
+
+func TestMomentumSteps(t *testing.T) {
+	// With a constant gradient of 1 the velocities of the first two steps are
+	// -0.1 and -0.15. Nesterov steps by 1.5 times the new velocity minus 0.5
+	// times the previous one.
+	for nesterov, expected := range map[bool]float64{false: -0.1 - 0.15, true: -0.15 - 0.175} {
+		p := Parameters{Values: []float64{0}, Gradients: []float64{1}}
+		o := NewMomentum(0.5, nesterov)
+		o.Update(0.1, []Parameters{p})
+		o.Update(0.1, []Parameters{p})
+		if math.Abs(p.Values[0]-expected) > 1e-12 {
+			t.Errorf("Nesterov %v: expected %v, got %v", nesterov, expected, p.Values[0])
+		}
+	}
+}
+
+func TestAdamFirstStep(t *testing.T) {
+	// Bias correction makes the first step the learning rate times the sign of the gradient
+	p := Parameters{Values: []float64{1, 1}, Gradients: []float64{0.5, -50}}
+	NewAdam().Update(0.1, []Parameters{p})
+	if math.Abs(p.Values[0]-0.9) > 1e-6 || math.Abs(p.Values[1]-1.1) > 1e-6 {
+		t.Errorf("Unexpected first step %v", p.Values)
+	}
+}
+
+func TestFeedForwardWithOptimizer(t *testing.T) {
+	net := NewFeedForwardWithOptions(Options{LearningRate: 0.05, Optimizer: NewAdam()}, NewInputLayer(2), NewLayer(1, nil))
+	inputs := [][]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}}
+	outputs := [][]float64{{0.5}, {2.5}, {-0.5}, {1.5}}
+
+	var loss float64
+	for i := 0; i < 2000; i++ {
+		var err error
+		if loss, err = net.TrainBatch(inputs, outputs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if loss > 1e-6 {
+		t.Errorf("Expected Adam to fit a linear function, loss = %v", loss)
+	}
+}
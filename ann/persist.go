@@ -0,0 +1,274 @@
+package ann
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"sync"
+)
+
+// Encoding used when saving and loading networks
+type Format int
+
+const (
+	// Human readable JSON
+	JSON Format = iota
+
+	// Compact binary encoding using encoding/gob
+	Binary
+)
+
+// Version of the saved network layout, increased whenever the layout changes
+// in a way older versions of this package cannot read
+const networkVersion = 1
+
+type (
+	networkModel struct {
+		Version      int
+		LearningRate float64
+		Loss         string
+		HuberDelta   float64 `json:",omitempty"`
+		Layers       []layerModel
+	}
+
+	// Saved form of a layer, the first layer of a network has no weights
+	layerModel struct {
+		Type       string
+		Size       int
+		Activation string    `json:",omitempty"`
+		Weights    []float64 `json:",omitempty"`
+		Biases     []float64 `json:",omitempty"`
+		L1         float64   `json:",omitempty"`
+		L2         float64   `json:",omitempty"`
+		Rate       float64   `json:",omitempty"`
+
+		// Batch normalization keeps its learned scale and shift in Weights and
+		// Biases
+		Mean     []float64 `json:",omitempty"`
+		Variance []float64 `json:",omitempty"`
+		Momentum float64   `json:",omitempty"`
+		Epsilon  float64   `json:",omitempty"`
+	}
+)
+
+// Write the layers, weights, learning rate and loss of the network in the
+// given format. The optimizer and its state are not saved, a loaded network
+// trains with plain gradient descent unless given another optimizer.
+func (net *FeedForward) Save(w io.Writer, format Format) error {
+	if err := net.rlock(); err != nil {
+		return err
+	}
+	defer net.mu.RUnlock()
+
+	if len(net.layers) < 2 {
+		return NotInitializedError
+	}
+
+	m := networkModel{Version: networkVersion, LearningRate: net.LearningRate}
+	switch l := net.Loss.(type) {
+	case *MeanSquaredError:
+		m.Loss = "mse"
+	case *BinaryCrossEntropy:
+		m.Loss = "binary_cross_entropy"
+	case *CategoricalCrossEntropy:
+		m.Loss = "categorical_cross_entropy"
+	case *HuberLoss:
+		m.Loss = "huber"
+		m.HuberDelta = l.Delta
+	default:
+		return UnsupportedLossError
+	}
+
+	// Only the size of the input layer matters
+	m.Layers = append(m.Layers, layerModel{Type: "input", Size: net.layers[0].Size()})
+	for _, l := range net.layers[1:] {
+		lm, err := l.save()
+		if err != nil {
+			return err
+		}
+		m.Layers = append(m.Layers, lm)
+	}
+
+	return encode(w, format, &m)
+}
+
+// Read a network written by FeedForward.Save
+func LoadFeedForward(r io.Reader, format Format) (FeedForward, error) {
+	var m networkModel
+	if err := decode(r, format, &m); err != nil {
+		return FeedForward{}, err
+	}
+	if m.Version < 1 || m.Version > networkVersion {
+		return FeedForward{}, UnsupportedVersionError
+	}
+	if len(m.Layers) < 2 {
+		return FeedForward{}, NotInitializedError
+	}
+
+	net := FeedForward{
+		LearningRate: m.LearningRate,
+		Optimizer:    new(SGD),
+		rng:          rand.New(rand.NewSource(rand.Int63())),
+		mu:           new(sync.RWMutex),
+	}
+	switch m.Loss {
+	case "mse":
+		net.Loss = new(MeanSquaredError)
+	case "binary_cross_entropy":
+		net.Loss = new(BinaryCrossEntropy)
+	case "categorical_cross_entropy":
+		net.Loss = new(CategoricalCrossEntropy)
+	case "huber":
+		net.Loss = NewHuberLoss(m.HuberDelta)
+	default:
+		return FeedForward{}, UnsupportedLossError
+	}
+
+	net.layers = append(net.layers, NewInputLayer(m.Layers[0].Size))
+	for _, lm := range m.Layers[1:] {
+		l, err := loadLayer(lm, net.layers[len(net.layers)-1].Size(), net.rng)
+		if err != nil {
+			return FeedForward{}, err
+		}
+		net.layers = append(net.layers, l)
+	}
+	return net, nil
+}
+
+func (l *DenseLayer) save() (layerModel, error) {
+	activation, err := saveActivation(l.activationFunc)
+	if err != nil {
+		return layerModel{}, err
+	}
+	return layerModel{
+		Type:       "dense",
+		Size:       l.size,
+		Activation: activation,
+		Weights:    l.weights.data,
+		Biases:     l.biases,
+		L1:         l.l1,
+		L2:         l.l2,
+	}, nil
+}
+
+func (l *SoftmaxLayer) save() (layerModel, error) {
+	m, err := l.DenseLayer.save()
+	m.Type = "softmax"
+	return m, err
+}
+
+func (l *BatchNormLayer) save() (layerModel, error) {
+	activation, err := saveActivation(l.activationFunc)
+	if err != nil {
+		return layerModel{}, err
+	}
+	return layerModel{
+		Type:       "batchnorm",
+		Size:       l.size,
+		Activation: activation,
+		Weights:    l.scale,
+		Biases:     l.shift,
+		Mean:       l.mean,
+		Variance:   l.variance,
+		Momentum:   l.Momentum,
+		Epsilon:    l.Epsilon,
+	}, nil
+}
+
+func (l *DropoutLayer) save() (layerModel, error) {
+	return layerModel{Type: "dropout", Size: l.size, Rate: l.rate}, nil
+}
+
+// Rebuild a layer following a layer of the given size
+func loadLayer(m layerModel, inputs int, rng *rand.Rand) (Layer, error) {
+	switch m.Type {
+	case "dense", "softmax":
+		activation, err := loadActivation(m.Activation)
+		if err != nil {
+			return nil, err
+		}
+		if len(m.Weights) != m.Size*inputs || len(m.Biases) != m.Size {
+			return nil, InvalidNetworkError
+		}
+
+		dense := NewLayerWithOptions(m.Size, activation, LayerOptions{L1: m.L1, L2: m.L2})
+		dense.weights = &matrix{rows: m.Size, cols: inputs, data: m.Weights}
+		dense.weightGradients = newMatrix(m.Size, inputs)
+		dense.biases = m.Biases
+		dense.biasGradients = make([]float64, m.Size)
+		if m.Type == "softmax" {
+			return &SoftmaxLayer{*dense}, nil
+		}
+		return dense, nil
+	case "batchnorm":
+		activation, err := loadActivation(m.Activation)
+		if err != nil {
+			return nil, err
+		}
+		if m.Size != inputs || len(m.Weights) != m.Size || len(m.Biases) != m.Size ||
+			len(m.Mean) != m.Size || len(m.Variance) != m.Size {
+			return nil, InvalidNetworkError
+		}
+
+		l := NewBatchNormLayer(activation)
+		l.connect(inputs, rng)
+		l.Momentum, l.Epsilon = m.Momentum, m.Epsilon
+		l.scale, l.shift, l.mean, l.variance = m.Weights, m.Biases, m.Mean, m.Variance
+		return l, nil
+	case "dropout":
+		if m.Size != inputs || m.Rate < 0 || m.Rate > maxDropoutRate {
+			return nil, InvalidNetworkError
+		}
+		return &DropoutLayer{rate: m.Rate, size: inputs, rng: rng}, nil
+	}
+	return nil, UnsupportedLayerError
+}
+
+func saveActivation(activationFunc ActivationFunction) (string, error) {
+	switch activationFunc.(type) {
+	case nil:
+		return "", nil
+	case *SigmoidActivation:
+		return "sigmoid", nil
+	case *ReLUActivation:
+		return "relu", nil
+	case *TanhActivation:
+		return "tanh", nil
+	}
+	return "", UnsupportedActivationError
+}
+
+func loadActivation(name string) (ActivationFunction, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "sigmoid":
+		return new(SigmoidActivation), nil
+	case "relu":
+		return new(ReLUActivation), nil
+	case "tanh":
+		return new(TanhActivation), nil
+	}
+	return nil, UnsupportedActivationError
+}
+
+func encode(w io.Writer, format Format, v interface{}) error {
+	switch format {
+	case JSON:
+		return json.NewEncoder(w).Encode(v)
+	case Binary:
+		return gob.NewEncoder(w).Encode(v)
+	}
+	return UnknownFormatError
+}
+
+func decode(r io.Reader, format Format, v interface{}) error {
+	switch format {
+	case JSON:
+		return json.NewDecoder(r).Decode(v)
+	case Binary:
+		return gob.NewDecoder(r).Decode(v)
+	}
+	return UnknownFormatError
+}
@@ -0,0 +1,89 @@
+package ann
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoadFeedForward(t *testing.T) {
+	inputs, outputs := randomDigits(16)
+	for name, net := range map[string]FeedForward{
+		"dense": NewFeedForwardWithOptions(Options{LearningRate: 0.1, Source: rand.NewSource(1)},
+			NewInputLayer(64),
+			NewLayer(16, new(ReLUActivation)),
+			NewLayer(8, new(TanhActivation)),
+			NewLayer(10, new(SigmoidActivation)),
+		),
+		"batchnorm": NewFeedForwardWithOptions(Options{LearningRate: 0.1, Source: rand.NewSource(1)},
+			NewInputLayer(64),
+			NewLayer(16, nil),
+			NewBatchNormLayer(new(TanhActivation)),
+			NewLayer(10, new(SigmoidActivation)),
+		),
+		"softmax": NewFeedForwardWithOptions(Options{LearningRate: 0.1, Loss: new(CategoricalCrossEntropy), Source: rand.NewSource(1)},
+			NewInputLayer(64),
+			NewLayer(16, nil),
+			NewSoftmaxLayer(10),
+		),
+	} {
+		for i := 0; i < 5; i++ {
+			if _, err := net.TrainBatch(inputs, outputs); err != nil {
+				t.Fatal(err)
+			}
+		}
+		expected, _ := net.PredictBatch(inputs)
+
+		for _, format := range []Format{JSON, Binary} {
+			var buf bytes.Buffer
+			if err := net.Save(&buf, format); err != nil {
+				t.Fatalf("%v: %v", name, err)
+			}
+			loaded, err := LoadFeedForward(&buf, format)
+			if err != nil {
+				t.Fatalf("%v: %v", name, err)
+			}
+			if reflect.TypeOf(loaded.Loss) != reflect.TypeOf(net.Loss) || loaded.LearningRate != net.LearningRate {
+				t.Errorf("%v: loss or learning rate not restored", name)
+			}
+			predictions, err := loaded.PredictBatch(inputs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(predictions, expected) {
+				t.Errorf("%v: loaded network predicts differently in format %v", name, format)
+			}
+			if _, err := loaded.TrainBatch(inputs, outputs); err != nil {
+				t.Errorf("%v: loaded network can not be trained: %v", name, err)
+			}
+		}
+	}
+}
+
+type customActivation struct{ SigmoidActivation }
+
+func TestSaveLoadErrors(t *testing.T) {
+	net := NewFeedForward(0.1, NewInputLayer(2), NewLayer(1, new(customActivation)))
+	if err := net.Save(new(bytes.Buffer), JSON); err != UnsupportedActivationError {
+		t.Errorf("Expected UnsupportedActivationError, got %v", err)
+	}
+
+	net = NewFeedForward(0.1, NewInputLayer(2), NewLayer(1, nil))
+	if err := net.Save(new(bytes.Buffer), Format(5)); err != UnknownFormatError {
+		t.Errorf("Expected UnknownFormatError, got %v", err)
+	}
+
+	for saved, expected := range map[string]error{
+		`{"Version":2,"Loss":"mse","Layers":[{"Type":"input","Size":2},{"Type":"dense","Size":1,"Weights":[1,2],"Biases":[0]}]}`:                      UnsupportedVersionError,
+		`{"Version":1,"Loss":"mse","Layers":[{"Type":"input","Size":2},{"Type":"dense","Size":1,"Weights":[1],"Biases":[0]}]}`:                        InvalidNetworkError,
+		`{"Version":1,"Loss":"mse","Layers":[{"Type":"input","Size":2},{"Type":"conv","Size":1,"Weights":[1,2],"Biases":[0]}]}`:                       UnsupportedLayerError,
+		`{"Version":1,"Loss":"hinge","Layers":[{"Type":"input","Size":2},{"Type":"dense","Size":1,"Weights":[1,2],"Biases":[0]}]}`:                    UnsupportedLossError,
+		`{"Version":1,"Loss":"mse","Layers":[{"Type":"input","Size":2},{"Type":"dense","Size":1,"Activation":"swish","Weights":[1,2],"Biases":[0]}]}`: UnsupportedActivationError,
+	} {
+		if _, err := LoadFeedForward(strings.NewReader(saved), JSON); err != expected {
+			t.Errorf("Expected %v loading %v, got %v", expected, saved, err)
+		}
+	}
+}
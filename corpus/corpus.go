@@ -0,0 +1,97 @@
+package corpus
+
+import (
+	"errors"
+	"io/ioutil"
+	"mime"
+	"net/mail"
+	"strings"
+
+	"github.com/emilsjolander/dexter/nba"
+)
+
+var (
+	MissingFieldError = errors.New("JSON lines record is missing its class or text field")
+)
+
+type (
+	// A labelled document read from a corpus
+	Document struct {
+		Class string
+
+		// The path of the document within the corpus, or its record number for JSON lines
+		Name string
+
+		// Mail style headers of the document, only set by WithHeaders
+		Header mail.Header
+
+		Text string
+	}
+
+	// A Reader streams the documents of a corpus one at a time. Next returns
+	// io.EOF once every document has been read.
+	Reader interface {
+		Next() (Document, error)
+	}
+
+	headerReader struct {
+		r Reader
+	}
+
+	stream struct {
+		r Reader
+	}
+)
+
+// Split mail style headers, such as the From and Subject lines starting each
+// 20 newsgroups post, off the text of every document. Header values encoded as
+// described in RFC 2047 are decoded. Documents without a header block are
+// returned unchanged.
+func WithHeaders(r Reader) Reader {
+	return &headerReader{r}
+}
+
+func (r *headerReader) Next() (Document, error) {
+	doc, err := r.r.Next()
+	if err != nil {
+		return doc, err
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(doc.Text))
+	if err != nil {
+		return doc, nil
+	}
+	body, err := ioutil.ReadAll(msg.Body)
+	if err != nil {
+		return doc, nil
+	}
+
+	decoder := new(mime.WordDecoder)
+	for key, values := range msg.Header {
+		for i, value := range values {
+			if decoded, err := decoder.DecodeHeader(value); err == nil {
+				values[i] = decoded
+			}
+		}
+		msg.Header[key] = values
+	}
+
+	doc.Header = msg.Header
+	doc.Text = string(body)
+	return doc, nil
+}
+
+// Adapt a Reader to the document stream consumed by nba.Multinomial.FitStream
+func Stream(r Reader) nba.DocumentStream {
+	return &stream{r}
+}
+
+func (s *stream) Next() (string, string, error) {
+	doc, err := s.r.Next()
+	return doc.Class, doc.Text, err
+}
+
+// Paths starting with a dot, like .DS_Store, are hidden files which are not part of a corpus
+func hidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
@@ -0,0 +1,233 @@
+package corpus
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/emilsjolander/dexter/nba"
+)
+
+// Read every document of a corpus
+func readAll(t *testing.T, r Reader) []Document {
+	var docs []Document
+	for {
+		doc, err := r.Next()
+		if err == io.EOF {
+			return docs
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		docs = append(docs, doc)
+	}
+}
+
+func TestDirReader(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"sports/1":         "the goal was great",
+		"sports/2":         "a late goal",
+		"sports/.DS_Store": "hidden",
+		"finance/1":        "the stock was up",
+		"empty/.keep":      "hidden",
+		".hidden/1":        "hidden",
+		"finance/nested/1": "not a document",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "README"), []byte("not a class"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Document{
+		{Class: "finance", Name: filepath.Join("finance", "1"), Text: "the stock was up"},
+		{Class: "sports", Name: filepath.Join("sports", "1"), Text: "the goal was great"},
+		{Class: "sports", Name: filepath.Join("sports", "2"), Text: "a late goal"},
+	}
+	if docs := readAll(t, r); !reflect.DeepEqual(docs, expected) {
+		t.Errorf("Read %v, expected %v", docs, expected)
+	}
+
+	if _, err := OpenDir(filepath.Join(root, "missing")); err == nil {
+		t.Error("Expected an error opening a missing directory")
+	}
+}
+
+func TestTarGzReader(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+	for _, entry := range []struct{ name, content string }{
+		{"train/sports/1", "the goal was great"},
+		{"train/sports/._1", "hidden"},
+		{"train/.git/config", "hidden"},
+		{"test/sports/1", "a late goal"},
+		{"train/finance/1", "the stock was up"},
+		{"train/finance/nested/1", "not a document"},
+		{"train/README", "no class"},
+		{"README", "no class"},
+	} {
+		header := &tar.Header{Name: entry.name, Mode: 0644, Size: int64(len(entry.content)), Typeflag: tar.TypeReg}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive.Close()
+	gz.Close()
+
+	r, err := NewTarGzReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Prefix = "train"
+	expected := []Document{
+		{Class: "sports", Name: "train/sports/1", Text: "the goal was great"},
+		{Class: "finance", Name: "train/finance/1", Text: "the stock was up"},
+	}
+	if docs := readAll(t, r); !reflect.DeepEqual(docs, expected) {
+		t.Errorf("Read %v, expected %v", docs, expected)
+	}
+}
+
+// The same corpus gives the same documents as a directory and as an archive
+func TestDirAndTarGzReadersAgree(t *testing.T) {
+	files := map[string]string{
+		"sports/1":         "the goal was great",
+		"sports/deep/1":    "nested",
+		"finance/1":        "the stock was up",
+		"finance/a/b/1":    "nested",
+		"finance/.hidden":  "hidden",
+		"top-level-file":   "no class",
+		"sports/2":         "a late goal",
+		".git/objects/abc": "hidden",
+	}
+
+	root := t.TempDir()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive.Close()
+	gz.Close()
+
+	dir, err := OpenDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarGz, err := NewTarGzReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Archives are read in their own order, compare the documents of each class
+	byClass := func(docs []Document) map[string][]string {
+		classes := make(map[string][]string)
+		for _, doc := range docs {
+			classes[doc.Class] = append(classes[doc.Class], doc.Text)
+		}
+		for _, texts := range classes {
+			sort.Strings(texts)
+		}
+		return classes
+	}
+	fromDir, fromTar := byClass(readAll(t, dir)), byClass(readAll(t, tarGz))
+	if !reflect.DeepEqual(fromDir, fromTar) {
+		t.Errorf("Directory gave %v, archive gave %v", fromDir, fromTar)
+	}
+	if len(fromDir["sports"]) != 2 || len(fromDir["finance"]) != 1 {
+		t.Errorf("Unexpected documents %v", fromDir)
+	}
+}
+
+func TestJSONLinesReader(t *testing.T) {
+	input := `{"class": "sports", "text": "the goal was great"}
+{"label": "finance", "body": "the stock was up", "id": 7}
+`
+	r := NewJSONLinesReader(strings.NewReader(input))
+	if doc, err := r.Next(); err != nil || doc.Class != "sports" || doc.Text != "the goal was great" || doc.Name != "1" {
+		t.Errorf("Unexpected document %v, error = %v", doc, err)
+	}
+	if _, err := r.Next(); err != MissingFieldError {
+		t.Errorf("Expected MissingFieldError, got %v", err)
+	}
+
+	r = NewJSONLinesReader(strings.NewReader(input))
+	r.ClassField = "label"
+	r.TextField = "body"
+	if _, err := r.Next(); err != MissingFieldError {
+		t.Errorf("Expected MissingFieldError, got %v", err)
+	}
+	if doc, err := r.Next(); err != nil || doc.Class != "finance" || doc.Name != "2" {
+		t.Errorf("Unexpected document %v, error = %v", doc, err)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestWithHeaders(t *testing.T) {
+	input := `{"class": "a", "text": "From: someone@example.com\nSubject: =?utf-8?q?caf=C3=A9?=\n\nThe body"}
+{"class": "a", "text": "No headers here"}
+`
+	docs := readAll(t, WithHeaders(NewJSONLinesReader(strings.NewReader(input))))
+
+	if docs[0].Text != "The body" || docs[0].Header.Get("Subject") != "café" || docs[0].Header.Get("From") != "someone@example.com" {
+		t.Errorf("Failed to split headers: %q %v", docs[0].Text, docs[0].Header)
+	}
+	if docs[1].Text != "No headers here" || docs[1].Header != nil {
+		t.Errorf("Expected document without headers to be unchanged: %q %v", docs[1].Text, docs[1].Header)
+	}
+}
+
+func TestStream(t *testing.T) {
+	input := `{"class": "sports", "text": "the goal was great"}
+{"class": "sports", "text": "a late goal"}
+{"class": "finance", "text": "the stock was up"}
+{"class": "finance", "text": "the stock was down"}
+`
+	classifier := nba.NewMultinomial(nba.NewWordTokenizer(), 1)
+	if err := classifier.FitStream(Stream(NewJSONLinesReader(strings.NewReader(input)))); err != nil {
+		t.Fatal(err)
+	}
+	if class, err := classifier.Classify("goal"); class != "sports" {
+		t.Errorf("Failed to classify sports: class = %v, error = %v", class, err)
+	}
+}
@@ -0,0 +1,65 @@
+package corpus
+
+import (
+	"io"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// DirReader reads a corpus laid out as one directory per class holding one
+// file per document, like 20news-bydate. Hidden files and directories are
+// skipped. Only the names in the current class directory are held in memory.
+type DirReader struct {
+	root    string
+	classes []string
+
+	// The index of the class being read and its documents which have not been read
+	class int
+	files []string
+}
+
+// Open a directory per class corpus rooted at dirName
+func OpenDir(dirName string) (*DirReader, error) {
+	children, err := ioutil.ReadDir(dirName)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &DirReader{root: dirName, class: -1}
+	for _, child := range children {
+		if child.IsDir() && !hidden(child.Name()) {
+			r.classes = append(r.classes, child.Name())
+		}
+	}
+	return r, nil
+}
+
+// The next document, classes and their documents are read in lexical order
+func (r *DirReader) Next() (Document, error) {
+	for len(r.files) == 0 {
+		if r.class+1 >= len(r.classes) {
+			return Document{}, io.EOF
+		}
+		r.class++
+
+		children, err := ioutil.ReadDir(filepath.Join(r.root, r.classes[r.class]))
+		if err != nil {
+			return Document{}, err
+		}
+		for _, child := range children {
+			if !child.IsDir() && !hidden(child.Name()) {
+				r.files = append(r.files, child.Name())
+			}
+		}
+	}
+
+	class := r.classes[r.class]
+	name := filepath.Join(class, r.files[0])
+	r.files = r.files[1:]
+
+	content, err := ioutil.ReadFile(filepath.Join(r.root, name))
+	if err != nil {
+		return Document{}, err
+	}
+	return Document{Class: class, Name: name, Text: string(content)}, nil
+}
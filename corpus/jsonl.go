@@ -0,0 +1,44 @@
+package corpus
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// JSONLinesReader reads a corpus stored as one JSON object per line, each
+// holding the class and text of a document as string fields
+type JSONLinesReader struct {
+	// Names of the fields holding the class and the text, "class" and "text" by default
+	ClassField string
+	TextField  string
+
+	decoder *json.Decoder
+	records int
+}
+
+func NewJSONLinesReader(r io.Reader) *JSONLinesReader {
+	return &JSONLinesReader{
+		ClassField: "class",
+		TextField:  "text",
+		decoder:    json.NewDecoder(r),
+	}
+}
+
+func (r *JSONLinesReader) Next() (Document, error) {
+	var record map[string]interface{}
+	if err := r.decoder.Decode(&record); err != nil {
+		return Document{}, err
+	}
+	r.records++
+
+	class, ok := record[r.ClassField].(string)
+	if !ok {
+		return Document{}, MissingFieldError
+	}
+	text, ok := record[r.TextField].(string)
+	if !ok {
+		return Document{}, MissingFieldError
+	}
+	return Document{Class: class, Name: strconv.Itoa(r.records), Text: text}, nil
+}
@@ -0,0 +1,74 @@
+package corpus
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// TarReader reads a directory per class corpus from a gzipped tar archive,
+// such as the 20news-bydate.tar.gz distribution, without extracting it. Like
+// DirReader the class directories are the top level directories of the
+// corpus and only the files directly inside them are documents. Files at the
+// top of the corpus, nested directories and hidden files and directories are
+// skipped.
+type TarReader struct {
+	// The directory within the archive holding the class directories, for
+	// example "20news-bydate-train". Empty reads class directories at the top
+	// of the archive.
+	Prefix string
+
+	archive *tar.Reader
+}
+
+// Read a gzipped tar archive
+func NewTarGzReader(r io.Reader) (*TarReader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &TarReader{archive: tar.NewReader(gz)}, nil
+}
+
+func (r *TarReader) Next() (Document, error) {
+	for {
+		header, err := r.archive.Next()
+		if err != nil {
+			return Document{}, err
+		}
+
+		name := strings.TrimPrefix(path.Clean(header.Name), "./")
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		relative := name
+		if prefix := strings.Trim(r.Prefix, "/"); prefix != "" {
+			if !strings.HasPrefix(name, prefix+"/") {
+				continue
+			}
+			relative = name[len(prefix)+1:]
+		}
+		parts := strings.Split(relative, "/")
+		if len(parts) != 2 || hiddenPath(strings.Split(name, "/")) {
+			continue
+		}
+
+		content, err := ioutil.ReadAll(r.archive)
+		if err != nil {
+			return Document{}, err
+		}
+		return Document{Class: parts[0], Name: name, Text: string(content)}, nil
+	}
+}
+
+func hiddenPath(parts []string) bool {
+	for _, part := range parts {
+		if hidden(part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package nba
+
+import (
+	"math"
+	"sync"
+)
+
+type (
+	// A data point where each feature takes one of a finite set of values
+	Categories []string
+
+	Categorical struct {
+		// Lidstone smoothing parameter added to every value count, 1 gives Laplace smoothing
+		Alpha float64
+
+		dimensionality int
+
+		// The prior probability of a class
+		classPriors map[string]float64
+
+		// The classes of classPriors in sorted order, kept so that classifying does not sort them
+		classes []string
+
+		// The number of points seen in a class
+		classCount map[string]float64
+
+		// The number of times each value of each feature has been seen in a class
+		valueCount map[string][]map[string]float64
+
+		// The distinct values seen for each feature
+		values []map[string]bool
+
+		// Guards the fitted state so that points can be classified while fitting
+		mu sync.RWMutex
+	}
+)
+
+// Construct a categorical naive bayes classifier. Alpha is the pseudo count
+// added to every value of every feature in every class and must be greater than 0.
+func NewCategorical(dimensionality int, alpha float64) *Categorical {
+	values := make([]map[string]bool, dimensionality)
+	for i := range values {
+		values[i] = make(map[string]bool)
+	}
+	return &Categorical{
+		Alpha:          alpha,
+		dimensionality: dimensionality,
+		classPriors:    make(map[string]float64),
+		classCount:     make(map[string]float64),
+		valueCount:     make(map[string][]map[string]float64),
+		values:         values,
+	}
+}
+
+func (nba *Categorical) Fit(data map[string][]Categories) error {
+	if len(data) < 1 {
+		return NoDataError
+	}
+	if nba.Alpha <= 0 {
+		return InvalidSmoothingError
+	}
+
+	totalPoints := 0
+	for _, points := range data {
+		for _, p := range points {
+			if len(p) != nba.dimensionality {
+				return WrongDimensionError
+			}
+		}
+		totalPoints += len(points)
+	}
+
+	nba.mu.Lock()
+	defer nba.mu.Unlock()
+
+	for class, points := range data {
+		// Calculate the prior of a class
+		nba.classPriors[class] = float64(len(points)) / float64(totalPoints)
+
+		if _, ok := nba.valueCount[class]; !ok {
+			nba.valueCount[class] = make([]map[string]float64, nba.dimensionality)
+			for i := range nba.valueCount[class] {
+				nba.valueCount[class][i] = make(map[string]float64)
+			}
+		}
+
+		for _, p := range points {
+			nba.classCount[class]++
+			for i, value := range p {
+				nba.valueCount[class][i][value]++
+				nba.values[i][value] = true
+			}
+		}
+	}
+	nba.classes = sortedClasses(nba.classPriors)
+
+	return nil
+}
+
+func (nba *Categorical) Classify(point Categories) (string, error) {
+	nba.mu.RLock()
+	defer nba.mu.RUnlock()
+	if len(point) != nba.dimensionality {
+		return "", WrongDimensionError
+	}
+	return classify(nba.classes, nba.classPriors, nba.logLikelihoods(point))
+}
+
+// The log likelihood of a point conditioned on each class
+func (nba *Categorical) logLikelihoods(point Categories) map[string]float64 {
+	logLikelihoods := make(map[string]float64, len(nba.classPriors))
+	for class := range nba.classPriors {
+		var logSum float64 = 0
+		for i, value := range point {
+			logSum += nba.logLikelihood(class, i, value)
+		}
+		logLikelihoods[class] = logSum
+	}
+	return logLikelihoods
+}
+
+// The smoothed log probability of a feature taking a value given a class
+func (nba *Categorical) logLikelihood(class string, feature int, value string) float64 {
+	count := nba.Alpha
+	if counts := nba.valueCount[class]; counts != nil {
+		count += counts[feature][value]
+	}
+	size := nba.classCount[class] + nba.Alpha*float64(len(nba.values[feature]))
+	return math.Log(count / size)
+}
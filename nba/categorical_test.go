@@ -0,0 +1,63 @@
+package nba
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+var categoricalData = map[string][]Categories{
+	"play": {{"sunny", "calm"}, {"overcast", "calm"}, {"sunny", "windy"}},
+	"stay": {{"rainy", "windy"}, {"rainy", "calm"}, {"overcast", "windy"}},
+}
+
+func TestCategoricalClassify(t *testing.T) {
+	classifier := NewCategorical(2, 1)
+	if err := classifier.Fit(categoricalData); err != nil {
+		t.Fatal(err)
+	}
+
+	if class, err := classifier.Classify(Categories{"sunny", "calm"}); class != "play" {
+		t.Errorf("Failed to classify play: class = %v, error = %v", class, err)
+	}
+	if class, err := classifier.Classify(Categories{"rainy", "windy"}); class != "stay" {
+		t.Errorf("Failed to classify stay: class = %v, error = %v", class, err)
+	}
+
+	// sunny was seen once out of 3 points in play, with 3 possible outlooks
+	if l := classifier.logLikelihood("play", 0, "sunny"); math.Abs(l-math.Log(3.0/6.0)) > 1e-12 {
+		t.Errorf("Unexpected log likelihood %v", l)
+	}
+
+	if _, err := classifier.Classify(Categories{"sunny"}); err != WrongDimensionError {
+		t.Errorf("Expected WrongDimensionError, got %v", err)
+	}
+}
+
+func TestCategoricalSaveLoad(t *testing.T) {
+	classifier := NewCategorical(2, 0.5)
+	if err := classifier.Fit(categoricalData); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []Format{JSON, Binary} {
+		var buf bytes.Buffer
+		if err := classifier.Save(&buf, format); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadCategorical(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, p := range []Categories{{"sunny", "calm"}, {"rainy", "windy"}, {"snowy", "calm"}} {
+			expected := classifier.logLikelihoods(p)
+			actual := loaded.logLikelihoods(p)
+			for class, l := range expected {
+				if actual[class] != l {
+					t.Errorf("Format %v changed log likelihood of %v for %v: %v != %v", format, class, p, actual[class], l)
+				}
+			}
+		}
+	}
+}
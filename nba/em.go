@@ -0,0 +1,196 @@
+package nba
+
+import (
+	"math"
+	"sort"
+)
+
+// The log likelihood of every feature in every class, and of features missing
+// from the vocabulary, so that repeated scoring avoids recomputing logarithms
+type logLikelihoodTable struct {
+	features map[string][]float64
+	unseen   map[string]float64
+}
+
+// Configuration of semi-supervised training with Multinomial.FitEM
+type EMOptions struct {
+	// Weight of an unlabelled document relative to a labelled one, between 0 and 1
+	UnlabelledWeight float64
+
+	// Stop once an iteration improves the log likelihood of the data by less
+	// than this fraction of its previous value
+	Tolerance float64
+
+	// Upper bound on the number of iterations
+	MaxIterations int
+}
+
+// Fit the model to labelled documents and then improve it using unlabelled
+// documents with expectation maximization, as described in Nigam et al. "Text
+// classification from labeled and unlabeled documents using EM", 2000.
+// Each iteration labels the unlabelled documents with the current model's
+// class posteriors and re-estimates the model with every unlabelled document
+// counted towards each class in proportion to its posterior and the
+// unlabelled weight. Returns the number of iterations that were run.
+func (nba *Multinomial) FitEM(labelled map[string][]string, unlabelled []string, options EMOptions) (int, error) {
+	nba.fitMu.Lock()
+	defer nba.fitMu.Unlock()
+	if len(labelled) < 1 {
+		return 0, NoDataError
+	}
+	if nba.Alpha <= 0 {
+		return 0, InvalidSmoothingError
+	}
+	if nba.vocabulary == nil && nba.buckets < 1 {
+		return 0, InvalidBucketsError
+	}
+	if options.UnlabelledWeight < 0 || options.UnlabelledWeight > 1 || options.Tolerance < 0 || options.MaxIterations < 1 {
+		return 0, InvalidEMOptionsError
+	}
+
+	// Tokenize concurrently and without holding the lock, as in Fit
+	classes := make([]string, 0, len(labelled))
+	for class := range labelled {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	labelledWords := make(map[string][][]string, len(labelled))
+	for _, class := range classes {
+		labelledWords[class] = nba.tokenizeAll(labelled[class])
+	}
+	unlabelledWords := nba.tokenizeAll(unlabelled)
+
+	nba.mu.Lock()
+	defer nba.mu.Unlock()
+
+	// The vocabulary and document frequencies include the unlabelled documents
+	var allDocs [][]string
+	for _, class := range classes {
+		for _, words := range labelledWords[class] {
+			allDocs = append(allDocs, words)
+			nba.learnVocabulary(words)
+		}
+	}
+	for _, words := range unlabelledWords {
+		allDocs = append(allDocs, words)
+		nba.learnVocabulary(words)
+	}
+	if nba.IDF {
+		nba.fitIDF(allDocs)
+	}
+
+	labelledFeatures := make(map[string][]map[int]float64, len(labelled))
+	for class, docs := range labelledWords {
+		for _, words := range docs {
+			labelledFeatures[class] = append(labelledFeatures[class], nba.features(words))
+		}
+	}
+	unlabelledFeatures := make([]map[int]float64, len(unlabelled))
+	for i, words := range unlabelledWords {
+		unlabelledFeatures[i] = nba.features(words)
+	}
+
+	// Start from the model of the labelled documents alone
+	nba.maximize(classes, labelledFeatures, nil, nil, 0)
+
+	iterations := 0
+	var previousLikelihood float64
+	for iterations < options.MaxIterations {
+		// Expectation: the class posteriors of every unlabelled document
+		table := nba.logLikelihoodTable()
+		likelihood := 0.0
+		for _, class := range classes {
+			for _, features := range labelledFeatures[class] {
+				likelihood += math.Log(nba.classPriors[class]) + table.logLikelihoods(features)[class]
+			}
+		}
+		responsibilities := make([]map[string]float64, len(unlabelledFeatures))
+		for i, features := range unlabelledFeatures {
+			var logEvidence float64
+			responsibilities[i], logEvidence = posteriors(nba.classes, nba.classPriors, table.logLikelihoods(features))
+			likelihood += options.UnlabelledWeight * logEvidence
+		}
+
+		if iterations > 0 && math.Abs(likelihood-previousLikelihood) <= options.Tolerance*math.Abs(previousLikelihood) {
+			break
+		}
+		previousLikelihood = likelihood
+
+		// Maximization: re-estimate the model from labelled and softly labelled documents
+		nba.maximize(classes, labelledFeatures, unlabelledFeatures, responsibilities, options.UnlabelledWeight)
+		iterations++
+	}
+
+	return iterations, nil
+}
+
+// Estimate priors and counts from scratch, counting each unlabelled document
+// towards every class by its responsibility times the unlabelled weight.
+// Classes are visited in sorted order so that the sums are reproducible.
+func (nba *Multinomial) maximize(classes []string, labelled map[string][]map[int]float64, unlabelled []map[int]float64, responsibilities []map[string]float64, unlabelledWeight float64) {
+	nba.classSize = make(map[string]float64, len(labelled))
+	nba.classPriors = make(map[string]float64, len(labelled))
+	nba.wordCount = make(map[string][]float64, len(labelled))
+
+	classWeight := make(map[string]float64, len(labelled))
+	var totalWeight float64
+	for _, class := range classes {
+		docs := labelled[class]
+		nba.growCounts(class)
+		for _, features := range docs {
+			nba.addCounts(class, features, 1)
+		}
+		classWeight[class] += float64(len(docs))
+		totalWeight += float64(len(docs))
+	}
+
+	for i, features := range unlabelled {
+		for _, class := range classes {
+			weight := unlabelledWeight * responsibilities[i][class]
+			nba.addCounts(class, features, weight)
+			classWeight[class] += weight
+			totalWeight += weight
+		}
+	}
+
+	// Calculate class priors
+	for _, class := range classes {
+		nba.classPriors[class] = classWeight[class] / totalWeight
+	}
+	nba.classes = sortedClasses(nba.classPriors)
+
+	nba.vocabularySize = nba.countSeenFeatures()
+}
+
+func (nba *Multinomial) logLikelihoodTable() *logLikelihoodTable {
+	table := &logLikelihoodTable{
+		features: make(map[string][]float64, len(nba.classPriors)),
+		unseen:   make(map[string]float64, len(nba.classPriors)),
+	}
+	for class := range nba.classPriors {
+		table.features[class] = make([]float64, nba.numFeatures())
+		for i := range table.features[class] {
+			table.features[class][i] = nba.logLikelihood(class, i)
+		}
+		table.unseen[class] = nba.logLikelihood(class, -1)
+	}
+	return table
+}
+
+// The log likelihood of a document's features conditioned on each class
+func (table *logLikelihoodTable) logLikelihoods(features map[int]float64) map[string]float64 {
+	indices := sortedFeatures(features)
+	logLikelihoods := make(map[string]float64, len(table.features))
+	for class, classTable := range table.features {
+		var logSum float64 = 0
+		for _, i := range indices {
+			if i >= 0 {
+				logSum += features[i] * classTable[i]
+			} else {
+				logSum += features[i] * table.unseen[class]
+			}
+		}
+		logLikelihoods[class] = logSum
+	}
+	return logLikelihoods
+}
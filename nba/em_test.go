@@ -0,0 +1,98 @@
+package nba
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestMultinomialFitEM(t *testing.T) {
+	labelled := map[string][]string{
+		"sports":  {"goal"},
+		"finance": {"stock"},
+	}
+	// Unlabelled documents link new words to the labelled ones
+	unlabelled := []string{
+		"goal match referee", "goal referee", "match goal",
+		"stock bond dividend", "bond stock", "dividend stock",
+	}
+
+	classifier := NewMultinomial(NewWordTokenizer(), 0.1)
+	iterations, err := classifier.FitEM(labelled, unlabelled, EMOptions{UnlabelledWeight: 1, Tolerance: 1e-6, MaxIterations: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iterations < 1 || iterations >= 50 {
+		t.Errorf("Expected EM to converge, ran %v iterations", iterations)
+	}
+
+	if class, err := classifier.Classify("referee match"); class != "sports" {
+		t.Errorf("Failed to classify sports: class = %v, error = %v", class, err)
+	}
+	if class, err := classifier.Classify("bond dividend"); class != "finance" {
+		t.Errorf("Failed to classify finance: class = %v, error = %v", class, err)
+	}
+}
+
+func TestMultinomialFitEMWithoutUnlabelledWeight(t *testing.T) {
+	em := NewMultinomial(NewWordTokenizer(), 1)
+	if _, err := em.FitEM(selectionData, nil, EMOptions{MaxIterations: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	fit := NewMultinomial(NewWordTokenizer(), 1)
+	if err := fit.Fit(selectionData); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, doc := range persistDocs {
+		if !reflect.DeepEqual(em.logLikelihoods(doc), fit.logLikelihoods(doc)) {
+			t.Errorf("EM without unlabelled data differs from Fit for %q", doc)
+		}
+	}
+
+	if _, err := em.FitEM(selectionData, nil, EMOptions{UnlabelledWeight: 2, MaxIterations: 1}); err != InvalidEMOptionsError {
+		t.Errorf("Expected InvalidEMOptionsError, got %v", err)
+	}
+}
+
+func TestMultinomialNewsgroupsEM(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping 20 newsgroups EM test in short mode")
+	}
+
+	training := readNewsgroups(t, filepath.Join(newsgroupsDir, "20news-bydate-train"))
+	test := readNewsgroups(t, filepath.Join(newsgroupsDir, "20news-bydate-test"))
+
+	// Keep the labels of only a few documents per class
+	labelled := make(map[string][]string)
+	var unlabelled []string
+	classes := make([]string, 0, len(training))
+	for class := range training {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		labelled[class] = training[class][:10]
+		unlabelled = append(unlabelled, training[class][10:]...)
+	}
+
+	supervised := NewMultinomial(NewWordTokenizer(), 0.1)
+	if err := supervised.Fit(labelled); err != nil {
+		t.Fatal(err)
+	}
+	supervisedAccuracy := accuracy(supervised, test)
+
+	semiSupervised := NewMultinomial(NewWordTokenizer(), 0.1)
+	_, err := semiSupervised.FitEM(labelled, unlabelled, EMOptions{UnlabelledWeight: 1, Tolerance: 1e-3, MaxIterations: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	semiSupervisedAccuracy := accuracy(semiSupervised, test)
+
+	t.Logf("Accuracy with 10 labels per class: supervised %.4f, EM %.4f", supervisedAccuracy, semiSupervisedAccuracy)
+	if semiSupervisedAccuracy <= supervisedAccuracy {
+		t.Errorf("Expected EM to improve on %.4f, got %.4f", supervisedAccuracy, semiSupervisedAccuracy)
+	}
+}
@@ -2,72 +2,47 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
-	"os"
-	"strings"
+	"io"
 
+	"github.com/emilsjolander/dexter/corpus"
 	"github.com/emilsjolander/dexter/nba"
 )
 
 func main() {
-	classifier := nba.NewMultinomial()
+	classifier := nba.NewMultinomial(nba.NewWordTokenizer(), 1)
 
-	training := readData("20news-bydate/20news-bydate-train")
-	testing := readData("20news-bydate/20news-bydate-test")
-
-	if err := classifier.Fit(training); err != nil {
+	training, err := corpus.OpenDir("20news-bydate/20news-bydate-train")
+	if err != nil {
 		panic(err)
 	}
-
-	total := 0
-	correct := 0
-	for class, docs := range testing {
-		for _, doc := range docs {
-			total++
-			result, err := classifier.Classify(doc)
-			if err == nil && class == result {
-				correct++
-			}
-		}
+	if err := classifier.FitStream(corpus.Stream(training)); err != nil {
+		panic(err)
 	}
 
-	fmt.Println("Total: ", total)
-	fmt.Println("Correct: ", correct)
-	fmt.Println("%: ", 100*(float64(correct)/float64(total)))
-}
-
-func readData(dirName string) map[string][]string {
-	children, err := ioutil.ReadDir(dirName)
+	testing, err := corpus.OpenDir("20news-bydate/20news-bydate-test")
 	if err != nil {
 		panic(err)
 	}
 
-	data := make(map[string][]string)
-
-	for _, child := range children {
-		if !strings.HasPrefix(child.Name(), ".") {
-			docs := make([]string, 0)
-
-			docFiles, err := ioutil.ReadDir(dirName + "/" + child.Name())
-			if err != nil {
-				panic(err)
-			}
-			for _, f := range docFiles {
-				contentFile, err := os.Open(dirName + "/" + child.Name() + "/" + f.Name())
-				if err != nil {
-					panic(err)
-				}
-				content, err := ioutil.ReadAll(contentFile)
-				if err != nil {
-					panic(err)
-				}
-				docs = append(docs, string(content))
-				contentFile.Close()
-			}
+	total := 0
+	correct := 0
+	for {
+		doc, err := testing.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			panic(err)
+		}
 
-			data[child.Name()] = docs
+		total++
+		result, err := classifier.Classify(doc.Text)
+		if err == nil && doc.Class == result {
+			correct++
 		}
 	}
 
-	return data
+	fmt.Println("Total: ", total)
+	fmt.Println("Correct: ", correct)
+	fmt.Println("%: ", 100*(float64(correct)/float64(total)))
 }
@@ -0,0 +1,195 @@
+package nba
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	// The log likelihood a single feature adds to a class's log probability
+	Contribution struct {
+		// The word, or dimension index for points, the contribution comes from
+		Feature       string
+		LogLikelihood float64
+	}
+
+	// Breakdown of how a model scored one class for a document or point
+	ClassExplanation struct {
+		Class    string
+		LogPrior float64
+
+		// Contributions in the order the features appear in the document or point
+		Features []Contribution
+
+		// LogPrior plus the sum of all contributions, the log posterior up to a constant
+		LogProbability float64
+
+		// The posterior probability of the class normalized over all classes
+		Posterior float64
+	}
+)
+
+// Score every class for a document and report each word's contribution to the
+// class's log probability. Classes are ordered from most to least probable.
+func (nba *Multinomial) Explain(doc string) ([]ClassExplanation, error) {
+	nba.mu.RLock()
+	defer nba.mu.RUnlock()
+	words := nba.Tokenizer.Tokenize(doc)
+	features := nba.features(words)
+
+	// Name each feature after the words mapping to it, keeping document order
+	var order []int
+	names := make(map[int][]string)
+	indices, _ := nba.featureIndices(words)
+	for j, i := range indices {
+		word := words[j]
+		if _, ok := features[i]; !ok {
+			continue
+		}
+		if _, ok := names[i]; !ok {
+			order = append(order, i)
+		}
+		if !containsString(names[i], word) {
+			names[i] = append(names[i], word)
+		}
+	}
+
+	// Log probabilities are summed in feature order, as in Classify, so that
+	// they match it exactly
+	logLikelihoods := nba.featureLogLikelihoods(features)
+	var explanations []ClassExplanation
+	for _, class := range nba.classes {
+		e := ClassExplanation{Class: class, LogPrior: math.Log(nba.classPriors[class])}
+		e.LogProbability = logLikelihoods[class] + e.LogPrior
+		for _, i := range order {
+			c := Contribution{strings.Join(names[i], "|"), features[i] * nba.logLikelihood(class, i)}
+			e.Features = append(e.Features, c)
+		}
+		explanations = append(explanations, e)
+	}
+
+	return normalizeExplanations(explanations)
+}
+
+// Score every class for a point and report each dimension's contribution to
+// the class's log probability. Classes are ordered from most to least probable.
+func (nba *Gaussian) Explain(point Point) ([]ClassExplanation, error) {
+	nba.mu.RLock()
+	defer nba.mu.RUnlock()
+	if len(point) != nba.dimensionality {
+		return nil, WrongDimensionError
+	}
+
+	var explanations []ClassExplanation
+	for _, class := range nba.classes {
+		e := ClassExplanation{Class: class, LogPrior: math.Log(nba.classPriors[class])}
+		e.LogProbability = e.LogPrior
+		for i, prior := range nba.classModel[class] {
+			c := Contribution{strconv.Itoa(i), prior.LogLikelihood(point[i])}
+			e.Features = append(e.Features, c)
+			e.LogProbability += c.LogLikelihood
+		}
+		explanations = append(explanations, e)
+	}
+
+	return normalizeExplanations(explanations)
+}
+
+// The n words whose presence most increases the odds of a class, scored by the
+// log ratio of the word's probability in the class to its probability in all
+// other classes combined.
+func (nba *Multinomial) MostIndicativeWords(class string, n int) ([]ScoredWord, error) {
+	if n < 0 {
+		return nil, InvalidFeatureCountError
+	}
+
+	nba.mu.RLock()
+	defer nba.mu.RUnlock()
+	if nba.vocabulary == nil {
+		return nil, NoVocabularyError
+	}
+	counts, ok := nba.wordCount[class]
+	if !ok {
+		return nil, UnknownClassError
+	}
+
+	// Sum the counts of every other class
+	rest := make([]float64, len(nba.vocabulary))
+	var restSize float64
+	for c, otherCounts := range nba.wordCount {
+		if c == class {
+			continue
+		}
+		for i, count := range otherCounts {
+			rest[i] += count
+		}
+		restSize += nba.classSize[c]
+	}
+	restSize += nba.Alpha * float64(nba.vocabularySize)
+
+	scored := make([]ScoredWord, 0, len(nba.vocabulary))
+	for word, i := range nba.vocabulary {
+		var count float64
+		if i < len(counts) {
+			count = counts[i]
+		}
+		if count == 0 {
+			continue
+		}
+		score := nba.logLikelihood(class, i) - math.Log((rest[i]+nba.Alpha)/restSize)
+		scored = append(scored, ScoredWord{word, score})
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].Word < scored[j].Word
+	})
+
+	if n < len(scored) {
+		scored = scored[:n]
+	}
+	return scored, nil
+}
+
+// Fill in posteriors and order explanations from most to least probable
+func normalizeExplanations(explanations []ClassExplanation) ([]ClassExplanation, error) {
+	if len(explanations) == 0 {
+		return nil, NoClassificationError
+	}
+
+	// Log-sum-exp relative to the largest log probability to avoid underflow
+	max := math.Inf(-1)
+	for _, e := range explanations {
+		max = math.Max(max, e.LogProbability)
+	}
+	if math.IsInf(max, -1) || math.IsNaN(max) {
+		return nil, UnderflowError
+	}
+	var sum float64
+	for _, e := range explanations {
+		sum += math.Exp(e.LogProbability - max)
+	}
+	for i := range explanations {
+		explanations[i].Posterior = math.Exp(explanations[i].LogProbability-max) / sum
+	}
+
+	sort.Slice(explanations, func(i, j int) bool {
+		if explanations[i].LogProbability != explanations[j].LogProbability {
+			return explanations[i].LogProbability > explanations[j].LogProbability
+		}
+		return explanations[i].Class < explanations[j].Class
+	})
+	return explanations, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package nba
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMultinomialExplain(t *testing.T) {
+	classifier := NewMultinomial(NewWordTokenizer(), 1)
+	if err := classifier.Fit(selectionData); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := "the goal was unexpected"
+	explanations, err := classifier.Explain(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	class, _ := classifier.Classify(doc)
+	if explanations[0].Class != class {
+		t.Errorf("Explanation ranks %v first but Classify returned %v", explanations[0].Class, class)
+	}
+
+	var posteriors float64
+	for _, e := range explanations {
+		posteriors += e.Posterior
+		if len(e.Features) != 4 || e.Features[3].Feature != "unexpected" {
+			t.Errorf("Unexpected features for class %v: %v", e.Class, e.Features)
+		}
+
+		sum := e.LogPrior
+		for _, c := range e.Features {
+			sum += c.LogLikelihood
+		}
+		if math.Abs(sum-e.LogProbability) > 1e-9 {
+			t.Errorf("Contributions of class %v sum to %v, expected %v", e.Class, sum, e.LogProbability)
+		}
+		if expected := classifier.logLikelihoods(doc)[e.Class] + e.LogPrior; e.LogProbability != expected {
+			t.Errorf("Log probability of class %v is %v, Classify scores it %v", e.Class, e.LogProbability, expected)
+		}
+	}
+	if math.Abs(posteriors-1) > 1e-9 {
+		t.Errorf("Posteriors sum to %v", posteriors)
+	}
+}
+
+func TestGaussianExplain(t *testing.T) {
+	classifier := NewGaussian(2)
+	err := classifier.Fit(map[string][]Point{
+		"low":  {{0, 0}, {1, 1}, {0, 1}},
+		"high": {{9, 9}, {10, 10}, {9, 10}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	explanations, err := classifier.Explain(Point{1, 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range explanations {
+		if len(e.Features) != 2 || e.Features[0].Feature != "0" || e.Features[1].Feature != "1" {
+			t.Errorf("Unexpected features for class %v: %v", e.Class, e.Features)
+		}
+	}
+
+	// The first dimension points towards low, the second towards high
+	low, high := explanations[0], explanations[1]
+	if low.Class != "low" {
+		low, high = high, low
+	}
+	if !(low.Features[0].LogLikelihood > high.Features[0].LogLikelihood && low.Features[1].LogLikelihood < high.Features[1].LogLikelihood) {
+		t.Errorf("Unexpected contributions: %v", explanations)
+	}
+
+	if _, err := classifier.Explain(Point{1}); err != WrongDimensionError {
+		t.Errorf("Expected WrongDimensionError, got %v", err)
+	}
+}
+
+func TestMostIndicativeWords(t *testing.T) {
+	classifier := NewMultinomial(NewWordTokenizer(), 1)
+	if err := classifier.Fit(selectionData); err != nil {
+		t.Fatal(err)
+	}
+
+	words, err := classifier.MostIndicativeWords("sports", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(words) != 1 || words[0].Word != "goal" {
+		t.Errorf("Expected goal to be most indicative of sports, got %v", words)
+	}
+
+	if _, err := classifier.MostIndicativeWords("weather", 1); err != UnknownClassError {
+		t.Errorf("Expected UnknownClassError, got %v", err)
+	}
+	if _, err := classifier.MostIndicativeWords("sports", -1); err != InvalidFeatureCountError {
+		t.Errorf("Expected InvalidFeatureCountError, got %v", err)
+	}
+
+	hashing := NewHashingMultinomial(NewWordTokenizer(), 1, 16, false)
+	if err := hashing.Fit(selectionData); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hashing.MostIndicativeWords("sports", 1); err != NoVocabularyError {
+		t.Errorf("Expected NoVocabularyError, got %v", err)
+	}
+}
@@ -1,117 +1,300 @@
 package nba
 
-import "math"
-
-const epsilon = 0.0001
+import (
+	"math"
+	"sort"
+	"sync"
+)
 
 type (
 	Point []float64
 
+	// A class conditional distribution over a single continuous feature
+	Distribution interface {
+		Likelihood(x float64) float64
+		LogLikelihood(x float64) float64
+	}
+
+	// How a continuous feature is modelled within each class
+	DistributionType int
+
 	GuassianDistribution struct {
 		Mean  float64
 		Sigma float64
 	}
 
 	Gaussian struct {
+		// Added to every variance so that features which are constant within
+		// a class do not produce zero or infinite likelihoods
+		VarEpsilon float64
+
+		// Fraction of the largest feature variance added to every variance on
+		// top of VarEpsilon, which scales the smoothing with the data
+		VarSmoothing float64
+
+		// Class priors to use instead of estimating them from the training data.
+		// Must contain every class of the training data and sum to 1.
+		Priors map[string]float64
+
+		// The distribution used for each feature, nil models every feature as a gaussian
+		FeatureDistributions []DistributionType
+
+		// Kernel bandwidth for each kernel density feature, nil or 0 picks a
+		// bandwidth using Silverman's rule of thumb
+		Bandwidths []float64
+
 		dimensionality int
 		classPriors    map[string]float64
-		classModel     map[string][]GuassianDistribution
+		classModel     map[string][]Distribution
+
+		// The classes of classPriors in sorted order, kept so that classifying does not sort them
+		classes []string
+
+		// Guards the fitted state so that points can be classified while fitting
+		mu sync.RWMutex
 	}
 )
 
+const (
+	// Normal distribution fit by the feature's mean and variance
+	GaussianFeature DistributionType = iota
+
+	// Gaussian kernel density estimate over the training values, for features
+	// which are skewed or have several modes
+	KernelDensityFeature
+)
+
 var sqrt2Pi = math.Sqrt(2.0 * math.Pi)
 
-func (n *GuassianDistribution) Likelihood(x float64) float64 {
+// Default absolute variance smoothing
+const defaultVarEpsilon = 0.0001
+
+func (n GuassianDistribution) Likelihood(x float64) float64 {
 	return (1.0 / (n.Sigma * sqrt2Pi)) * math.Exp(-(x-n.Mean)*(x-n.Mean)/(2.0*n.Sigma*n.Sigma))
 }
 
+func (n GuassianDistribution) LogLikelihood(x float64) float64 {
+	return -math.Log(n.Sigma*sqrt2Pi) - (x-n.Mean)*(x-n.Mean)/(2.0*n.Sigma*n.Sigma)
+}
+
 func NewGaussian(dimensionality int) *Gaussian {
 	return &Gaussian{
+		VarEpsilon:     defaultVarEpsilon,
 		dimensionality: dimensionality,
 		classPriors:    make(map[string]float64),
-		classModel:     make(map[string][]GuassianDistribution),
+		classModel:     make(map[string][]Distribution),
 	}
 }
 
 func (nba *Gaussian) Fit(data map[string][]Point) error {
+	return nba.FitWeighted(data, nil)
+}
+
+// Fit the model where each point counts according to its weight. Weights are
+// given per class in the same order as the points, a nil map weighs every
+// point equally.
+func (nba *Gaussian) FitWeighted(data map[string][]Point, weights map[string][]float64) error {
+	nba.mu.Lock()
+	defer nba.mu.Unlock()
+
 	if len(data) < 1 {
 		return NoDataError
 	}
+	if nba.FeatureDistributions != nil && len(nba.FeatureDistributions) != nba.dimensionality {
+		return WrongDimensionError
+	}
+	if nba.Bandwidths != nil && len(nba.Bandwidths) != nba.dimensionality {
+		return WrongDimensionError
+	}
+
+	// Sum over classes in sorted order so that fitting is reproducible
+	classes := make([]string, 0, len(data))
+	for class := range data {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	var totalWeight float64
+	for _, class := range classes {
+		points := data[class]
+		if len(points) < 1 {
+			return NoDataError
+		}
+		if weights != nil && len(weights[class]) != len(points) {
+			return WeightMismatchError
+		}
+		for i, p := range points {
+			if len(p) != nba.dimensionality {
+				return WrongDimensionError
+			}
+			w := pointWeight(weights, class, i)
+			if w < 0 {
+				return InvalidWeightError
+			}
+			totalWeight += w
+		}
+	}
+	if totalWeight <= 0 {
+		return InvalidWeightError
+	}
 
-	totalPoints := 0
-	var allPoints []Point
-	for _, points := range data {
-		if len(points[0]) != nba.dimensionality {
-			return WrongDimensionError
+	if nba.Priors != nil {
+		if err := validatePriors(nba.Priors, data); err != nil {
+			return err
 		}
-		totalPoints += len(points)
-		allPoints = append(allPoints, points...)
+	}
+
+	// Variances are smoothed by a constant plus a fraction of the largest
+	// variance of any feature
+	epsilon := nba.VarEpsilon
+	if nba.VarSmoothing != 0 {
+		epsilon += nba.VarSmoothing * maxVariance(classes, data, weights, nba.dimensionality)
 	}
 
 	for class, points := range data {
+		var classWeight float64
+		for i := range points {
+			classWeight += pointWeight(weights, class, i)
+		}
+
 		// Calculate the prior of a class
-		nba.classPriors[class] = float64(len(points)) / float64(totalPoints)
+		if nba.Priors != nil {
+			nba.classPriors[class] = nba.Priors[class]
+		} else {
+			nba.classPriors[class] = classWeight / totalWeight
+		}
 
-		// Calculate the mean and variance of each point dimension with respect its class
+		// Calculate the weighted mean and variance of each point dimension with respect its class
 		mean := make(Point, nba.dimensionality)
 		variance := make([]float64, nba.dimensionality)
 		for i := 0; i < nba.dimensionality; i++ {
-			// Calculate mean
-			for _, p := range points {
-				mean[i] += p[i]
-			}
-			mean[i] /= float64(len(points))
+			if classWeight > 0 {
+				// Calculate mean
+				for j, p := range points {
+					mean[i] += pointWeight(weights, class, j) * p[i]
+				}
+				mean[i] /= classWeight
 
-			// Calculate variance
-			for _, p := range points {
-				variance[i] += (mean[i] - p[i]) * (mean[i] - p[i])
+				// Calculate variance
+				for j, p := range points {
+					variance[i] += pointWeight(weights, class, j) * (mean[i] - p[i]) * (mean[i] - p[i])
+				}
+				variance[i] /= classWeight
 			}
-			variance[i] /= float64(len(points))
 
 			// Add epsilon to avoid 0 probability
 			variance[i] += epsilon
 		}
 
-		// Make guassian distributions for class models
-		nba.classModel[class] = make([]GuassianDistribution, nba.dimensionality)
+		// Make the distributions for class models
+		nba.classModel[class] = make([]Distribution, nba.dimensionality)
 		for i := 0; i < nba.dimensionality; i++ {
-			nba.classModel[class][i] = GuassianDistribution{mean[i], math.Sqrt(variance[i])}
+			if nba.featureDistribution(i) == KernelDensityFeature {
+				nba.classModel[class][i] = nba.fitKernelDensity(points, weights[class], i, epsilon)
+			} else {
+				nba.classModel[class][i] = GuassianDistribution{mean[i], math.Sqrt(variance[i])}
+			}
 		}
 	}
+	nba.classes = sortedClasses(nba.classPriors)
 
 	return nil
 }
 
 func (nba *Gaussian) Classify(point Point) (string, error) {
+	nba.mu.RLock()
+	defer nba.mu.RUnlock()
 	if len(point) != nba.dimensionality {
 		return "", WrongDimensionError
 	}
+	return classify(nba.classes, nba.classPriors, nba.logLikelihoods(point))
+}
 
-	var bestClass string
-	var bestClassLogProbability float64 = -math.MaxFloat64
-
-	for class, classPrior := range nba.classPriors {
-
+// The log likelihood of a point conditioned on each class
+func (nba *Gaussian) logLikelihoods(point Point) map[string]float64 {
+	logLikelihoods := make(map[string]float64, len(nba.classModel))
+	for class, model := range nba.classModel {
 		// Get the total class model for the point conditiond on this class
 		var logSum float64 = 0
-		for i, prior := range nba.classModel[class] {
-			logSum += math.Log(prior.Likelihood(point[i]))
+		for i, prior := range model {
+			logSum += prior.LogLikelihood(point[i])
 		}
+		logLikelihoods[class] = logSum
+	}
+	return logLikelihoods
+}
 
-		// Bayes theorem: P(c|e) = (P(e|c)P(c)) / P(e)
-		// We drop P(e) as it is constant
-		logProbability := logSum + math.Log(classPrior)
+func (nba *Gaussian) featureDistribution(i int) DistributionType {
+	if nba.FeatureDistributions == nil {
+		return GaussianFeature
+	}
+	return nba.FeatureDistributions[i]
+}
 
-		// Update current best class
-		if logProbability > bestClassLogProbability {
-			bestClassLogProbability = logProbability
-			bestClass = class
-		}
+// Fit a kernel density estimate to dimension i of a class's points
+func (nba *Gaussian) fitKernelDensity(points []Point, weights []float64, i int, epsilon float64) *KernelDensity {
+	samples := make([]float64, len(points))
+	for j, p := range points {
+		samples[j] = p[i]
+	}
+
+	var bandwidth float64
+	if nba.Bandwidths != nil {
+		bandwidth = nba.Bandwidths[i]
+	}
+	if bandwidth <= 0 {
+		// Smooth the bandwidth like the variance of gaussian features so constant features still have width
+		bandwidth = math.Sqrt(math.Pow(SilvermanBandwidth(samples, weights), 2) + epsilon)
+	}
+	return &KernelDensity{Samples: samples, Weights: weights, Bandwidth: bandwidth}
+}
+
+func pointWeight(weights map[string][]float64, class string, i int) float64 {
+	if weights == nil {
+		return 1
 	}
+	return weights[class][i]
+}
 
-	if bestClassLogProbability == -math.MaxFloat64 {
-		return "", NoClassificationError
+// The largest weighted variance of any feature over all classes
+func maxVariance(classes []string, data map[string][]Point, weights map[string][]float64, dimensionality int) float64 {
+	var max float64
+	for i := 0; i < dimensionality; i++ {
+		var sum, sumSq, total float64
+		for _, class := range classes {
+			for j, p := range data[class] {
+				w := pointWeight(weights, class, j)
+				sum += w * p[i]
+				sumSq += w * p[i] * p[i]
+				total += w
+			}
+		}
+		mean := sum / total
+		max = math.Max(max, sumSq/total-mean*mean)
 	}
+	if max <= 0 {
+		// Every feature is constant, fall back to absolute smoothing
+		return 1
+	}
+	return max
+}
 
-	return bestClass, nil
+// Check that priors cover every class and form a distribution
+func validatePriors(priors map[string]float64, data map[string][]Point) error {
+	var sum float64
+	for _, p := range priors {
+		if p < 0 {
+			return InvalidPriorsError
+		}
+		sum += p
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		return InvalidPriorsError
+	}
+	for class := range data {
+		if _, ok := priors[class]; !ok {
+			return InvalidPriorsError
+		}
+	}
+	return nil
 }
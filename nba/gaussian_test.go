@@ -0,0 +1,162 @@
+package nba
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+var gaussianData = map[string][]Point{
+	"low":  {{0, 0}, {1, 1}, {0, 1}},
+	"high": {{9, 9}, {10, 10}, {9, 10}},
+}
+
+func TestGaussianClassify(t *testing.T) {
+	classifier := NewGaussian(2)
+	if err := classifier.Fit(gaussianData); err != nil {
+		t.Fatal(err)
+	}
+
+	if class, err := classifier.Classify(Point{1, 0}); class != "low" {
+		t.Errorf("Failed to classify low: class = %v, error = %v", class, err)
+	}
+	if class, err := classifier.Classify(Point{9, 11}); class != "high" {
+		t.Errorf("Failed to classify high: class = %v, error = %v", class, err)
+	}
+}
+
+func TestGaussianFitWeighted(t *testing.T) {
+	// Weighing a point by 2 is the same as seeing it twice
+	weighted := NewGaussian(2)
+	err := weighted.FitWeighted(gaussianData, map[string][]float64{
+		"low":  {2, 1, 1},
+		"high": {1, 1, 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	duplicated := NewGaussian(2)
+	err = duplicated.Fit(map[string][]Point{
+		"low":  {{0, 0}, {0, 0}, {1, 1}, {0, 1}},
+		"high": {{9, 9}, {10, 10}, {9, 10}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(weighted.classPriors, duplicated.classPriors) {
+		t.Errorf("Priors differ: %v != %v", weighted.classPriors, duplicated.classPriors)
+	}
+	for class, model := range weighted.classModel {
+		for i, d := range model {
+			d := d.(GuassianDistribution)
+			expected := duplicated.classModel[class][i].(GuassianDistribution)
+			if math.Abs(d.Mean-expected.Mean) > 1e-12 || math.Abs(d.Sigma-expected.Sigma) > 1e-12 {
+				t.Errorf("Distribution %v of class %v differs: %v != %v", i, class, d, expected)
+			}
+		}
+	}
+
+	if err := weighted.FitWeighted(gaussianData, map[string][]float64{"low": {1}}); err != WeightMismatchError {
+		t.Errorf("Expected WeightMismatchError, got %v", err)
+	}
+	if err := weighted.FitWeighted(gaussianData, map[string][]float64{"low": {-1, 1, 1}, "high": {1, 1, 1}}); err != InvalidWeightError {
+		t.Errorf("Expected InvalidWeightError, got %v", err)
+	}
+}
+
+func TestGaussianPriors(t *testing.T) {
+	classifier := NewGaussian(2)
+	classifier.Priors = map[string]float64{"low": 0.999999, "high": 0.000001}
+	if err := classifier.Fit(gaussianData); err != nil {
+		t.Fatal(err)
+	}
+
+	// Halfway between the classes the prior decides
+	if class, err := classifier.Classify(Point{5, 5}); class != "low" {
+		t.Errorf("Expected the prior to favour low: class = %v, error = %v", class, err)
+	}
+
+	classifier.Priors = map[string]float64{"low": 1}
+	if err := classifier.Fit(gaussianData); err != InvalidPriorsError {
+		t.Errorf("Expected InvalidPriorsError, got %v", err)
+	}
+}
+
+func TestGaussianVarSmoothingIsRelative(t *testing.T) {
+	// A feature which is constant within each class gets a variance relative to the data's scale
+	data := map[string][]Point{"a": {{0}, {0}}, "b": {{1}, {1}}}
+	scaled := map[string][]Point{"a": {{0}, {0}}, "b": {{1000}, {1000}}}
+
+	classifier := NewGaussian(1)
+	classifier.VarEpsilon = 0
+	classifier.VarSmoothing = 0.01
+	if err := classifier.Fit(data); err != nil {
+		t.Fatal(err)
+	}
+	sigma := classifier.classModel["a"][0].(GuassianDistribution).Sigma
+
+	if err := classifier.Fit(scaled); err != nil {
+		t.Fatal(err)
+	}
+	if scaledSigma := classifier.classModel["a"][0].(GuassianDistribution).Sigma; math.Abs(scaledSigma/sigma-1000) > 1e-6 {
+		t.Errorf("Expected smoothing to scale with the data, got sigmas %v and %v", sigma, scaledSigma)
+	}
+}
+
+// Pins the accuracy of the default model on the optdigits test set
+func TestGaussianOptdigitsAccuracy(t *testing.T) {
+	training := readOptdigits(t, filepath.Join("example_gaussian", "optdigits.tra"))
+	test := readOptdigits(t, filepath.Join("example_gaussian", "optdigits.tes"))
+
+	classifier := NewGaussian(64)
+	if err := classifier.Fit(training); err != nil {
+		t.Fatal(err)
+	}
+
+	var correct, total int
+	for class, points := range test {
+		for _, p := range points {
+			if c, err := classifier.Classify(p); err == nil && c == class {
+				correct++
+			}
+			total++
+		}
+	}
+	if acc := float64(correct) / float64(total); acc < 0.87 {
+		t.Errorf("Accuracy of default classifier dropped to %.4f, expected at least 0.87", acc)
+	} else {
+		t.Logf("Accuracy of default classifier: %.4f", acc)
+	}
+}
+
+// Read an optdigits csv file of 64 pixel values followed by the digit
+func readOptdigits(t *testing.T, fileName string) map[string][]Point {
+	file, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := make(map[string][]Point)
+	for _, row := range records {
+		point := make(Point, len(row)-1)
+		for i, item := range row[:len(row)-1] {
+			if point[i], err = strconv.ParseFloat(item, 64); err != nil {
+				t.Fatal(err)
+			}
+		}
+		class := row[len(row)-1]
+		data[class] = append(data[class], point)
+	}
+	return data
+}
@@ -0,0 +1,111 @@
+package nba
+
+import (
+	"math"
+	"sort"
+)
+
+// KernelDensity is a gaussian kernel density estimate of a continuous feature.
+// The density at x is the weighted average of normal distributions with a
+// standard deviation of Bandwidth centered on each sample.
+type KernelDensity struct {
+	Samples []float64
+
+	// Weight of each sample, nil weighs every sample equally
+	Weights []float64
+
+	Bandwidth float64
+}
+
+func (k *KernelDensity) Likelihood(x float64) float64 {
+	return math.Exp(k.LogLikelihood(x))
+}
+
+func (k *KernelDensity) LogLikelihood(x float64) float64 {
+	if len(k.Samples) == 0 {
+		return math.Inf(-1)
+	}
+
+	// Sum the kernels in log space relative to the closest sample so that
+	// points far from every sample do not underflow to 0
+	logKernels := make([]float64, len(k.Samples))
+	max := math.Inf(-1)
+	for i, s := range k.Samples {
+		z := (x - s) / k.Bandwidth
+		logKernels[i] = -z * z / 2
+		if k.weight(i) > 0 {
+			max = math.Max(max, logKernels[i])
+		}
+	}
+	if math.IsInf(max, -1) {
+		return max
+	}
+
+	var sum, totalWeight float64
+	for i, l := range logKernels {
+		sum += k.weight(i) * math.Exp(l-max)
+		totalWeight += k.weight(i)
+	}
+	return max + math.Log(sum/totalWeight) - math.Log(k.Bandwidth*sqrt2Pi)
+}
+
+func (k *KernelDensity) weight(i int) float64 {
+	if k.Weights == nil {
+		return 1
+	}
+	return k.Weights[i]
+}
+
+// Silverman's rule of thumb for the bandwidth of a gaussian kernel density
+// estimate: 0.9 * min(standard deviation, interquartile range / 1.34) * n^(-1/5).
+// Weighted samples use the weighted spread and the effective sample size.
+func SilvermanBandwidth(samples []float64, weights []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	weight := func(i int) float64 {
+		if weights == nil {
+			return 1
+		}
+		return weights[i]
+	}
+
+	var sum, sumSq, total, totalSq float64
+	for i, s := range samples {
+		w := weight(i)
+		sum += w * s
+		sumSq += w * s * s
+		total += w
+		totalSq += w * w
+	}
+	if total <= 0 {
+		return 0
+	}
+	mean := sum / total
+	sigma := math.Sqrt(math.Max(0, sumSq/total-mean*mean))
+
+	// Order samples to find the weighted quartiles
+	order := make([]int, len(samples))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return samples[order[a]] < samples[order[b]] })
+	quantile := func(q float64) float64 {
+		var cumulative float64
+		for _, i := range order {
+			cumulative += weight(i)
+			if cumulative >= q*total {
+				return samples[i]
+			}
+		}
+		return samples[order[len(order)-1]]
+	}
+
+	spread := sigma
+	if iqr := (quantile(0.75) - quantile(0.25)) / 1.34; iqr > 0 && iqr < spread {
+		spread = iqr
+	}
+
+	n := total * total / totalSq
+	return 0.9 * spread * math.Pow(n, -0.2)
+}
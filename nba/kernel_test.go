@@ -0,0 +1,107 @@
+package nba
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestKernelDensityIntegratesToOne(t *testing.T) {
+	k := &KernelDensity{Samples: []float64{-1, 0, 4}, Weights: []float64{1, 2, 1}, Bandwidth: 0.5}
+
+	var integral float64
+	for x := -10.0; x < 15; x += 0.001 {
+		integral += k.Likelihood(x) * 0.001
+	}
+	if math.Abs(integral-1) > 1e-6 {
+		t.Errorf("Density integrates to %v", integral)
+	}
+
+	// Far from every sample the log likelihood stays finite
+	if l := k.LogLikelihood(1e4); math.IsInf(l, 0) || math.IsNaN(l) {
+		t.Errorf("Expected a finite log likelihood, got %v", l)
+	}
+}
+
+func TestSilvermanBandwidth(t *testing.T) {
+	samples := []float64{1, 2, 3, 4, 5}
+	// Standard deviation sqrt(2) is smaller than the interquartile range 2 / 1.34
+	expected := 0.9 * math.Sqrt(2) * math.Pow(5, -0.2)
+	if h := SilvermanBandwidth(samples, nil); math.Abs(h-expected) > 1e-12 {
+		t.Errorf("Expected bandwidth %v, got %v", expected, h)
+	}
+
+	// Unit weights are the same as no weights
+	if h := SilvermanBandwidth(samples, []float64{1, 1, 1, 1, 1}); math.Abs(h-expected) > 1e-12 {
+		t.Errorf("Expected bandwidth %v, got %v", expected, h)
+	}
+}
+
+// Class a is bimodal so a gaussian places most of its mass between the modes
+var bimodalData = map[string][]Point{
+	"a": {{-3.1}, {-3}, {-2.9}, {2.9}, {3}, {3.1}},
+	"b": {{-6}, {-3}, {0}, {0}, {3}, {6}},
+}
+
+func TestKernelDensityFeature(t *testing.T) {
+	gaussian := NewGaussian(1)
+	if err := gaussian.Fit(bimodalData); err != nil {
+		t.Fatal(err)
+	}
+	if class, _ := gaussian.Classify(Point{0}); class != "a" {
+		t.Errorf("Expected the gaussian model to pick the mean of a, got %v", class)
+	}
+
+	kernel := NewGaussian(1)
+	kernel.FeatureDistributions = []DistributionType{KernelDensityFeature}
+	if err := kernel.Fit(bimodalData); err != nil {
+		t.Fatal(err)
+	}
+	if class, err := kernel.Classify(Point{0}); class != "b" {
+		t.Errorf("Failed to classify b: class = %v, error = %v", class, err)
+	}
+	if class, err := kernel.Classify(Point{3}); class != "a" {
+		t.Errorf("Failed to classify a: class = %v, error = %v", class, err)
+	}
+
+	kernel.Bandwidths = []float64{0.25}
+	if err := kernel.Fit(bimodalData); err != nil {
+		t.Fatal(err)
+	}
+	if h := kernel.classModel["a"][0].(*KernelDensity).Bandwidth; h != 0.25 {
+		t.Errorf("Expected the user set bandwidth, got %v", h)
+	}
+
+	kernel.FeatureDistributions = []DistributionType{KernelDensityFeature, GaussianFeature}
+	if err := kernel.Fit(bimodalData); err != WrongDimensionError {
+		t.Errorf("Expected WrongDimensionError, got %v", err)
+	}
+}
+
+func TestKernelDensitySaveLoad(t *testing.T) {
+	classifier := NewGaussian(2)
+	classifier.FeatureDistributions = []DistributionType{KernelDensityFeature, GaussianFeature}
+	if err := classifier.Fit(gaussianData); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []Format{JSON, Binary} {
+		var buf bytes.Buffer
+		if err := classifier.Save(&buf, format); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGaussian(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, p := range []Point{{0, 0}, {5, 5}, {1, 9}} {
+			expected, _ := classifier.Explain(p)
+			actual, _ := loaded.Explain(p)
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("Format %v changed prediction for %v: %v != %v", format, p, actual, expected)
+			}
+		}
+	}
+}
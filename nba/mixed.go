@@ -0,0 +1,265 @@
+package nba
+
+import "sync"
+
+type (
+	// The kind of data a feature group holds
+	FeatureKind int
+
+	// FeatureGroup describes one named group of features of a record and the
+	// distribution used to model it
+	FeatureGroup struct {
+		Name string
+		Kind FeatureKind
+
+		// Number of features in numeric and categorical groups
+		Dimensionality int
+
+		// Distribution of each feature in a numeric group, nil models every feature as a gaussian
+		Distributions []DistributionType
+
+		// Splits the documents of a text group into words
+		Tokenizer Tokenizer
+
+		// Lidstone smoothing for categorical and text groups
+		Alpha float64
+	}
+
+	// A record holding a value for every group of a schema, keyed by group name
+	Record struct {
+		Numeric     map[string]Point
+		Categorical map[string]Categories
+		Text        map[string]string
+	}
+
+	// Mixed combines numeric, categorical and text features in one naive bayes
+	// model. Every group is modelled independently given the class and their
+	// log likelihoods are summed under a prior shared by all groups.
+	Mixed struct {
+		schema      []FeatureGroup
+		classPriors map[string]float64
+		classes     []string
+		numeric     map[string]*Gaussian
+		categorical map[string]*Categorical
+		text        map[string]*Multinomial
+
+		// Guards the fitted state so that records can be classified while fitting
+		mu sync.RWMutex
+	}
+)
+
+const (
+	// Continuous features modelled by a Gaussian
+	NumericFeatures FeatureKind = iota
+
+	// Discrete features modelled by a Categorical
+	CategoricalFeatures
+
+	// A document modelled by a Multinomial
+	TextFeatures
+)
+
+// Construct a mixed naive bayes classifier for records following the schema.
+// Group names must be unique and text groups need a tokenizer.
+func NewMixed(schema ...FeatureGroup) (*Mixed, error) {
+	if err := validateSchema(schema); err != nil {
+		return nil, err
+	}
+	return newMixed(schema), nil
+}
+
+func newMixed(schema []FeatureGroup) *Mixed {
+	nba := &Mixed{
+		schema:      schema,
+		classPriors: make(map[string]float64),
+		numeric:     make(map[string]*Gaussian),
+		categorical: make(map[string]*Categorical),
+		text:        make(map[string]*Multinomial),
+	}
+
+	for _, group := range schema {
+		switch group.Kind {
+		case NumericFeatures:
+			g := NewGaussian(group.Dimensionality)
+			g.FeatureDistributions = group.Distributions
+			nba.numeric[group.Name] = g
+		case CategoricalFeatures:
+			nba.categorical[group.Name] = NewCategorical(group.Dimensionality, group.Alpha)
+		case TextFeatures:
+			nba.text[group.Name] = NewMultinomial(group.Tokenizer, group.Alpha)
+		}
+	}
+
+	return nba
+}
+
+func validateSchema(schema []FeatureGroup) error {
+	names := make(map[string]bool, len(schema))
+	for _, group := range schema {
+		if names[group.Name] {
+			return DuplicateFeatureGroupError
+		}
+		names[group.Name] = true
+
+		switch group.Kind {
+		case NumericFeatures:
+			if group.Dimensionality < 1 {
+				return InvalidDimensionalityError
+			}
+			if group.Distributions != nil && len(group.Distributions) != group.Dimensionality {
+				return WrongDimensionError
+			}
+		case CategoricalFeatures:
+			if group.Dimensionality < 1 {
+				return InvalidDimensionalityError
+			}
+			if group.Alpha <= 0 {
+				return InvalidSmoothingError
+			}
+		case TextFeatures:
+			if group.Tokenizer == nil {
+				return MissingTokenizerError
+			}
+			if group.Alpha <= 0 {
+				return InvalidSmoothingError
+			}
+		default:
+			return UnknownFeatureKindError
+		}
+	}
+	return nil
+}
+
+// Fit every group to the records, replacing any earlier fit. Groups are fit
+// into new models which only replace the current ones once all have succeeded.
+func (nba *Mixed) Fit(data map[string][]Record) error {
+	if len(data) < 1 {
+		return NoDataError
+	}
+
+	totalRecords := 0
+	for _, records := range data {
+		if len(records) < 1 {
+			return NoDataError
+		}
+		for _, r := range records {
+			if err := nba.checkRecord(r); err != nil {
+				return err
+			}
+		}
+		totalRecords += len(records)
+	}
+
+	// Split the records into the training data of each group
+	fitted := newMixed(nba.schema)
+	for _, group := range nba.schema {
+		var err error
+		switch group.Kind {
+		case NumericFeatures:
+			groupData := make(map[string][]Point, len(data))
+			for class, records := range data {
+				for _, r := range records {
+					groupData[class] = append(groupData[class], r.Numeric[group.Name])
+				}
+			}
+			err = fitted.numeric[group.Name].Fit(groupData)
+		case CategoricalFeatures:
+			groupData := make(map[string][]Categories, len(data))
+			for class, records := range data {
+				for _, r := range records {
+					groupData[class] = append(groupData[class], r.Categorical[group.Name])
+				}
+			}
+			err = fitted.categorical[group.Name].Fit(groupData)
+		case TextFeatures:
+			groupData := make(map[string][]string, len(data))
+			for class, records := range data {
+				for _, r := range records {
+					groupData[class] = append(groupData[class], r.Text[group.Name])
+				}
+			}
+			err = fitted.text[group.Name].Fit(groupData)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Calculate class priors shared by all groups
+	for class, records := range data {
+		fitted.classPriors[class] = float64(len(records)) / float64(totalRecords)
+	}
+	fitted.classes = sortedClasses(fitted.classPriors)
+
+	nba.mu.Lock()
+	defer nba.mu.Unlock()
+	nba.classPriors = fitted.classPriors
+	nba.classes = fitted.classes
+	nba.numeric = fitted.numeric
+	nba.categorical = fitted.categorical
+	nba.text = fitted.text
+
+	return nil
+}
+
+func (nba *Mixed) Classify(record Record) (string, error) {
+	nba.mu.RLock()
+	defer nba.mu.RUnlock()
+	if err := nba.checkRecord(record); err != nil {
+		return "", err
+	}
+	return classify(nba.classes, nba.classPriors, nba.logLikelihoods(record))
+}
+
+// The log likelihood of a record conditioned on each class, the sum over all groups
+func (nba *Mixed) logLikelihoods(record Record) map[string]float64 {
+	logLikelihoods := make(map[string]float64, len(nba.classPriors))
+	add := func(groupLikelihoods map[string]float64) {
+		for class, l := range groupLikelihoods {
+			logLikelihoods[class] += l
+		}
+	}
+
+	for _, group := range nba.schema {
+		switch group.Kind {
+		case NumericFeatures:
+			add(nba.numeric[group.Name].logLikelihoods(record.Numeric[group.Name]))
+		case CategoricalFeatures:
+			add(nba.categorical[group.Name].logLikelihoods(record.Categorical[group.Name]))
+		case TextFeatures:
+			add(nba.text[group.Name].logLikelihoods(record.Text[group.Name]))
+		}
+	}
+	return logLikelihoods
+}
+
+// Make sure a record has a value of the right size for every group
+func (nba *Mixed) checkRecord(r Record) error {
+	for _, group := range nba.schema {
+		switch group.Kind {
+		case NumericFeatures:
+			p, ok := r.Numeric[group.Name]
+			if !ok {
+				return MissingFeatureGroupError
+			}
+			if len(p) != group.Dimensionality {
+				return WrongDimensionError
+			}
+		case CategoricalFeatures:
+			p, ok := r.Categorical[group.Name]
+			if !ok {
+				return MissingFeatureGroupError
+			}
+			if len(p) != group.Dimensionality {
+				return WrongDimensionError
+			}
+		case TextFeatures:
+			if _, ok := r.Text[group.Name]; !ok {
+				return MissingFeatureGroupError
+			}
+		default:
+			return UnknownFeatureKindError
+		}
+	}
+	return nil
+}
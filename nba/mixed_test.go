@@ -0,0 +1,149 @@
+package nba
+
+import (
+	"bytes"
+	"testing"
+)
+
+var mixedSchema = []FeatureGroup{
+	{Name: "size", Kind: NumericFeatures, Dimensionality: 1},
+	{Name: "colour", Kind: CategoricalFeatures, Dimensionality: 1, Alpha: 1},
+	{Name: "description", Kind: TextFeatures, Tokenizer: NewWordTokenizer(), Alpha: 1},
+}
+
+func mixedRecord(size float64, colour string, description string) Record {
+	return Record{
+		Numeric:     map[string]Point{"size": {size}},
+		Categorical: map[string]Categories{"colour": {colour}},
+		Text:        map[string]string{"description": description},
+	}
+}
+
+var mixedData = map[string][]Record{
+	"apple": {
+		mixedRecord(7, "red", "crisp and sweet"),
+		mixedRecord(8, "green", "sour and crisp"),
+		mixedRecord(7.5, "red", "sweet juicy"),
+	},
+	"melon": {
+		mixedRecord(25, "green", "juicy and sweet"),
+		mixedRecord(30, "yellow", "very juicy"),
+		mixedRecord(28, "green", "large and juicy"),
+	},
+}
+
+func TestMixedClassify(t *testing.T) {
+	classifier, err := NewMixed(mixedSchema...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := classifier.Fit(mixedData); err != nil {
+		t.Fatal(err)
+	}
+
+	if class, err := classifier.Classify(mixedRecord(7, "red", "crisp")); class != "apple" {
+		t.Errorf("Failed to classify apple: class = %v, error = %v", class, err)
+	}
+	if class, err := classifier.Classify(mixedRecord(27, "yellow", "juicy")); class != "melon" {
+		t.Errorf("Failed to classify melon: class = %v, error = %v", class, err)
+	}
+
+	// The groups are summed, so the log likelihood is the sum of each group's model
+	record := mixedRecord(10, "green", "sweet and juicy")
+	total := classifier.logLikelihoods(record)
+	size := classifier.numeric["size"].logLikelihoods(record.Numeric["size"])
+	colour := classifier.categorical["colour"].logLikelihoods(record.Categorical["colour"])
+	description := classifier.text["description"].logLikelihoods(record.Text["description"])
+	for class, l := range total {
+		if sum := size[class] + colour[class] + description[class]; sum != l {
+			t.Errorf("Log likelihood of %v is %v, expected %v", class, l, sum)
+		}
+	}
+
+	if _, err := classifier.Classify(Record{}); err != MissingFeatureGroupError {
+		t.Errorf("Expected MissingFeatureGroupError, got %v", err)
+	}
+}
+
+func TestMixedSaveLoad(t *testing.T) {
+	classifier, err := NewMixed(mixedSchema...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := classifier.Fit(mixedData); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []Format{JSON, Binary} {
+		var buf bytes.Buffer
+		if err := classifier.Save(&buf, format); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadMixed(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, r := range []Record{mixedRecord(7, "red", "crisp"), mixedRecord(20, "blue", "unknown words")} {
+			expected := classifier.logLikelihoods(r)
+			actual := loaded.logLikelihoods(r)
+			for class, l := range expected {
+				if actual[class] != l {
+					t.Errorf("Format %v changed log likelihood of %v: %v != %v", format, class, actual[class], l)
+				}
+			}
+		}
+	}
+}
+
+func TestMixedSchemaErrors(t *testing.T) {
+	for _, test := range []struct {
+		schema   []FeatureGroup
+		expected error
+	}{
+		{[]FeatureGroup{{Name: "size", Kind: NumericFeatures, Dimensionality: 1}, {Name: "size", Kind: CategoricalFeatures, Dimensionality: 1, Alpha: 1}}, DuplicateFeatureGroupError},
+		{[]FeatureGroup{{Name: "description", Kind: TextFeatures, Alpha: 1}}, MissingTokenizerError},
+		{[]FeatureGroup{{Name: "size", Kind: FeatureKind(7)}}, UnknownFeatureKindError},
+		{[]FeatureGroup{{Name: "size", Kind: NumericFeatures}}, InvalidDimensionalityError},
+		{[]FeatureGroup{{Name: "size", Kind: NumericFeatures, Dimensionality: 2, Distributions: []DistributionType{KernelDensityFeature}}}, WrongDimensionError},
+		{[]FeatureGroup{{Name: "colour", Kind: CategoricalFeatures, Alpha: 1}}, InvalidDimensionalityError},
+		{[]FeatureGroup{{Name: "colour", Kind: CategoricalFeatures, Dimensionality: 1}}, InvalidSmoothingError},
+		{[]FeatureGroup{{Name: "description", Kind: TextFeatures, Tokenizer: NewWordTokenizer(), Alpha: -1}}, InvalidSmoothingError},
+	} {
+		if _, err := NewMixed(test.schema...); err != test.expected {
+			t.Errorf("Expected %v, got %v", test.expected, err)
+		}
+	}
+}
+
+func TestMixedFitIsAtomic(t *testing.T) {
+	classifier, err := NewMixed(mixedSchema...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := classifier.Fit(mixedData); err != nil {
+		t.Fatal(err)
+	}
+	if err := classifier.Fit(map[string][]Record{"apple": mixedData["apple"], "empty": {}}); err != NoDataError {
+		t.Errorf("Expected NoDataError, got %v", err)
+	}
+
+	// Break the numeric group so that the text group is fit before it fails
+	classifier.schema = []FeatureGroup{mixedSchema[2], mixedSchema[0]}
+	classifier.schema[1].Distributions = []DistributionType{GaussianFeature, GaussianFeature}
+	text := classifier.text["description"]
+	before := classifier.logLikelihoods(mixedRecord(7, "red", "crisp"))
+
+	if err := classifier.Fit(map[string][]Record{"apple": mixedData["apple"]}); err != WrongDimensionError {
+		t.Fatalf("Expected WrongDimensionError, got %v", err)
+	}
+	if classifier.text["description"] != text || len(classifier.classPriors) != 2 {
+		t.Errorf("Failed fit replaced the fitted models")
+	}
+	after := classifier.logLikelihoods(mixedRecord(7, "red", "crisp"))
+	for class, l := range before {
+		if after[class] != l {
+			t.Errorf("Failed fit changed log likelihood of %v: %v != %v", class, after[class], l)
+		}
+	}
+}
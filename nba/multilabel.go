@@ -0,0 +1,251 @@
+package nba
+
+import (
+	"sort"
+	"sync"
+)
+
+const (
+	// Binary classes of the per label models
+	inLabel  = "in"
+	outLabel = "out"
+
+	// Posterior probability needed to assign a label which has no threshold set
+	defaultThreshold = 0.5
+)
+
+// MultiLabel assigns any number of labels to a document using binary
+// relevance: one Multinomial per label decides whether the label applies,
+// independently of all other labels.
+type MultiLabel struct {
+	// Posterior probability each label needs for it to be assigned
+	thresholds map[string]float64
+
+	newModel func() *Multinomial
+	models   map[string]*Multinomial
+
+	// Guards the models and thresholds so that documents can be classified while fitting
+	mu sync.RWMutex
+}
+
+// Construct a multi label classifier. newModel is called once per label to
+// create its binary model, so every label is modelled with the same
+// tokenizer and options.
+func NewMultiLabel(newModel func() *Multinomial) *MultiLabel {
+	return &MultiLabel{
+		thresholds: make(map[string]float64),
+		newModel:   newModel,
+		models:     make(map[string]*Multinomial),
+	}
+}
+
+// Fit one binary model per label, labels[i] holds the labels of docs[i]. The
+// models replace those of any earlier fit, so labels absent from the new data
+// are no longer assigned. Thresholds are kept.
+func (nba *MultiLabel) Fit(docs []string, labels [][]string) error {
+	if len(docs) < 1 {
+		return NoDataError
+	}
+	if len(docs) != len(labels) {
+		return LenMismatchError
+	}
+
+	// Fit the new models before taking the lock so that classification
+	// continues with the previous models in the meantime
+	models := make(map[string]*Multinomial)
+	for _, label := range labelSet(labels) {
+		data := make(map[string][]string)
+		for i, doc := range docs {
+			if containsString(labels[i], label) {
+				data[inLabel] = append(data[inLabel], doc)
+			} else {
+				data[outLabel] = append(data[outLabel], doc)
+			}
+		}
+
+		model := nba.newModel()
+		if err := model.Fit(data); err != nil {
+			return err
+		}
+		models[label] = model
+	}
+
+	nba.mu.Lock()
+	defer nba.mu.Unlock()
+	nba.models = models
+	return nil
+}
+
+// The labels whose posterior probability reaches their threshold, in sorted order
+func (nba *MultiLabel) Classify(doc string) ([]string, error) {
+	nba.mu.RLock()
+	defer nba.mu.RUnlock()
+	if len(nba.models) == 0 {
+		return nil, NotFittedError
+	}
+
+	labels := []string{}
+	for label, p := range nba.probabilities(doc) {
+		if p >= nba.threshold(label) {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+	return labels, nil
+}
+
+// The posterior probability of each label applying to a document
+func (nba *MultiLabel) Probabilities(doc string) map[string]float64 {
+	nba.mu.RLock()
+	defer nba.mu.RUnlock()
+	return nba.probabilities(doc)
+}
+
+func (nba *MultiLabel) probabilities(doc string) map[string]float64 {
+	probabilities := make(map[string]float64, len(nba.models))
+	for label, model := range nba.models {
+		p, _ := posteriors(model.classes, model.classPriors, model.logLikelihoods(doc))
+		probabilities[label] = p[inLabel]
+	}
+	return probabilities
+}
+
+// Pick the threshold of every label that maximizes its F1 score on held out
+// documents, docs should not be part of the data the model was fit to
+func (nba *MultiLabel) FitThresholds(docs []string, labels [][]string) error {
+	if len(docs) < 1 {
+		return NoDataError
+	}
+	if len(docs) != len(labels) {
+		return LenMismatchError
+	}
+
+	nba.mu.Lock()
+	defer nba.mu.Unlock()
+
+	probabilities := make([]map[string]float64, len(docs))
+	for i, doc := range docs {
+		probabilities[i] = nba.probabilities(doc)
+	}
+
+	for label := range nba.models {
+		// Every distinct probability is a candidate threshold
+		candidates := make([]float64, len(docs))
+		for i := range docs {
+			candidates[i] = probabilities[i][label]
+		}
+
+		best, bestF1 := defaultThreshold, -1.0
+		for _, threshold := range candidates {
+			var truePositives, falsePositives, falseNegatives float64
+			for i := range docs {
+				predicted := probabilities[i][label] >= threshold
+				actual := containsString(labels[i], label)
+				switch {
+				case predicted && actual:
+					truePositives++
+				case predicted:
+					falsePositives++
+				case actual:
+					falseNegatives++
+				}
+			}
+
+			f1 := 2 * truePositives / (2*truePositives + falsePositives + falseNegatives)
+			if f1 > bestF1 || (f1 == bestF1 && threshold > best) {
+				best, bestF1 = threshold, f1
+			}
+		}
+		nba.thresholds[label] = best
+	}
+
+	return nil
+}
+
+// Set the posterior probability a label needs for it to be assigned
+func (nba *MultiLabel) SetThreshold(label string, threshold float64) {
+	nba.mu.Lock()
+	defer nba.mu.Unlock()
+	nba.thresholds[label] = threshold
+}
+
+// The posterior probability a label needs for it to be assigned, 0.5 for
+// labels without a threshold
+func (nba *MultiLabel) Threshold(label string) float64 {
+	nba.mu.RLock()
+	defer nba.mu.RUnlock()
+	return nba.threshold(label)
+}
+
+func (nba *MultiLabel) threshold(label string) float64 {
+	if t, ok := nba.thresholds[label]; ok {
+		return t
+	}
+	return defaultThreshold
+}
+
+// The fraction of label decisions that are wrong, averaged over every
+// document and every label appearing in either the predicted or actual sets
+func HammingLoss(predicted, actual [][]string) (float64, error) {
+	if len(predicted) < 1 {
+		return 0, NoDataError
+	}
+	if len(predicted) != len(actual) {
+		return 0, LenMismatchError
+	}
+
+	labels := labelSet(append(append([][]string{}, predicted...), actual...))
+	if len(labels) == 0 {
+		return 0, nil
+	}
+
+	var wrong int
+	for i := range predicted {
+		for _, label := range labels {
+			if containsString(predicted[i], label) != containsString(actual[i], label) {
+				wrong++
+			}
+		}
+	}
+	return float64(wrong) / float64(len(predicted)*len(labels)), nil
+}
+
+// The fraction of documents whose predicted label set exactly matches the actual set
+func SubsetAccuracy(predicted, actual [][]string) (float64, error) {
+	if len(predicted) < 1 {
+		return 0, NoDataError
+	}
+	if len(predicted) != len(actual) {
+		return 0, LenMismatchError
+	}
+
+	var correct int
+	for i := range predicted {
+		p := labelSet([][]string{predicted[i]})
+		a := labelSet([][]string{actual[i]})
+		if len(p) == len(a) {
+			equal := true
+			for j := range p {
+				if p[j] != a[j] {
+					equal = false
+					break
+				}
+			}
+			if equal {
+				correct++
+			}
+		}
+	}
+	return float64(correct) / float64(len(predicted)), nil
+}
+
+// The sorted distinct labels of a set of label lists
+func labelSet(labels [][]string) []string {
+	set := make(map[string]bool)
+	for _, l := range labels {
+		for _, label := range l {
+			set[label] = true
+		}
+	}
+	return sortedKeys(set)
+}
@@ -0,0 +1,129 @@
+package nba
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+var multiLabelDocs = []string{
+	"goal scored in the match",
+	"the referee stopped the match",
+	"stock market prices fell",
+	"interest rates and stock prices",
+	"club shares rise after the match",
+	"football club stock listed on the market",
+}
+
+var multiLabelLabels = [][]string{
+	{"sports"},
+	{"sports"},
+	{"finance"},
+	{"finance"},
+	{"finance", "sports"},
+	{"finance", "sports"},
+}
+
+func newMultiLabel() *MultiLabel {
+	return NewMultiLabel(func() *Multinomial {
+		return NewMultinomial(NewWordTokenizer(), 1)
+	})
+}
+
+func TestMultiLabelClassify(t *testing.T) {
+	classifier := newMultiLabel()
+	if err := classifier.Fit(multiLabelDocs, multiLabelLabels); err != nil {
+		t.Fatal(err)
+	}
+
+	for doc, expected := range map[string][]string{
+		"the match referee":        {"sports"},
+		"stock prices":             {"finance"},
+		"club stock after a match": {"finance", "sports"},
+	} {
+		labels, err := classifier.Classify(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(labels, expected) {
+			t.Errorf("Classified %q as %v, expected %v", doc, labels, expected)
+		}
+	}
+
+	// A threshold above every probability removes the label
+	classifier.SetThreshold("sports", 1.1)
+	if labels, _ := classifier.Classify("the match referee"); len(labels) != 0 {
+		t.Errorf("Expected no labels, got %v", labels)
+	}
+	if classifier.Threshold("sports") != 1.1 || classifier.Threshold("finance") != defaultThreshold {
+		t.Errorf("Unexpected thresholds %v and %v", classifier.Threshold("sports"), classifier.Threshold("finance"))
+	}
+
+	// Refitting replaces the models, labels missing from the new fit are dropped
+	if err := classifier.Fit(multiLabelDocs[2:4], multiLabelLabels[2:4]); err != nil {
+		t.Fatal(err)
+	}
+	if labels, _ := classifier.Classify("club stock after a match"); !reflect.DeepEqual(labels, []string{"finance"}) {
+		t.Errorf("Expected only the refit label, got %v", labels)
+	}
+
+	if err := classifier.Fit(multiLabelDocs, multiLabelLabels[:1]); err != LenMismatchError {
+		t.Errorf("Expected LenMismatchError, got %v", err)
+	}
+	if _, err := newMultiLabel().Classify("doc"); err != NotFittedError {
+		t.Errorf("Expected NotFittedError, got %v", err)
+	}
+}
+
+func TestMultiLabelFitThresholds(t *testing.T) {
+	classifier := newMultiLabel()
+	if err := classifier.Fit(multiLabelDocs, multiLabelLabels); err != nil {
+		t.Fatal(err)
+	}
+
+	hammingLoss := func() float64 {
+		predicted := make([][]string, len(multiLabelDocs))
+		for i, doc := range multiLabelDocs {
+			predicted[i], _ = classifier.Classify(doc)
+		}
+		loss, _ := HammingLoss(predicted, multiLabelLabels)
+		return loss
+	}
+
+	defaultLoss := hammingLoss()
+	if err := classifier.FitThresholds(multiLabelDocs, multiLabelLabels); err != nil {
+		t.Fatal(err)
+	}
+	if len(classifier.thresholds) != 2 {
+		t.Errorf("Expected a threshold per label, got %v", classifier.thresholds)
+	}
+	if loss := hammingLoss(); loss > defaultLoss {
+		t.Errorf("Tuned thresholds increased hamming loss from %v to %v", defaultLoss, loss)
+	}
+
+	// Thresholds set before tuning do not change the outcome
+	tuned := classifier.thresholds
+	classifier.thresholds = map[string]float64{"sports": 1.1, "finance": -1}
+	if err := classifier.FitThresholds(multiLabelDocs, multiLabelLabels); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(classifier.thresholds, tuned) {
+		t.Errorf("Tuned thresholds %v depend on earlier thresholds, expected %v", classifier.thresholds, tuned)
+	}
+}
+
+func TestMultiLabelMetrics(t *testing.T) {
+	predicted := [][]string{{"a"}, {"a", "b"}, {}, {"c"}}
+	actual := [][]string{{"a"}, {"b", "a"}, {"b"}, {"a"}}
+
+	// 3 wrong decisions out of 4 documents times 3 labels
+	if loss, err := HammingLoss(predicted, actual); err != nil || math.Abs(loss-3.0/12.0) > 1e-12 {
+		t.Errorf("Unexpected hamming loss %v, error = %v", loss, err)
+	}
+	if acc, err := SubsetAccuracy(predicted, actual); err != nil || acc != 0.5 {
+		t.Errorf("Unexpected subset accuracy %v, error = %v", acc, err)
+	}
+	if _, err := SubsetAccuracy(predicted, actual[:1]); err != LenMismatchError {
+		t.Errorf("Expected LenMismatchError, got %v", err)
+	}
+}
@@ -1,108 +1,434 @@
 package nba
 
 import (
+	"hash/fnv"
 	"math"
-	"strings"
+	"sort"
+	"sync"
 )
 
 type (
 	Multinomial struct {
+		// Splits documents into words
+		Tokenizer Tokenizer
+
+		// Lidstone smoothing parameter added to every word count, 1 gives Laplace smoothing
+		Alpha float64
+
+		// Term frequency transforms as described in Rennie et al. "Tackling the
+		// poor assumptions of naive bayes text classifiers", 2003. They are applied
+		// to the word counts of a document both when fitting and classifying.
+		// Replace a word count c with log(1 + c)
+		SublinearTF bool
+		// Weight word counts by their inverse document frequency in the training
+		// data. Each Fit then replaces the previous one rather than adding to it.
+		IDF bool
+		// Scale the word counts of each document to unit euclidean length
+		Normalize bool
+
+		// The number of hash buckets words are mapped to, 0 if an explicit vocabulary is kept
+		buckets int
+
+		// Flip the sign of words based on their hash so that collisions cancel out
+		signed bool
+
+		// The index of each word's count, nil when words are hashed into buckets
+		vocabulary map[string]int
+
 		// The number of words in the vocabulary
 		vocabularySize int
 
-		// The total number of words in a class
-		classSize map[string]int
+		// The total weight of words in a class
+		classSize map[string]float64
 
 		// The prior probability of a class
 		classPriors map[string]float64
 
-		// The number of times a word has been seen in a class
-		wordCount map[string]map[string]int
-	}
-)
+		// The classes of classPriors in sorted order, kept so that classifying does not sort them
+		classes []string
+
+		// The weighted number of times a word has been seen in a class, indexed by
+		// the word's position in the vocabulary or by its hash bucket
+		wordCount map[string][]float64
+
+		// The inverse document frequency of each word, only used when IDF is set
+		idf []float64
+
+		// The number of documents the inverse document frequencies were calculated from
+		idfDocs int
 
-func words(doc string) []string {
-	words := strings.Split(string(doc), " ")
-	for i, word := range words {
-		words[i] = strings.ToLower(word)
+		// Guards the fitted state so that documents can be classified while fitting
+		mu sync.RWMutex
+
+		// Serializes fits, so that a fit from a stream is not overtaken by another fit
+		fitMu sync.Mutex
 	}
-	return words
-}
+)
 
-func NewMultinomial() *Multinomial {
+// Construct a multinomial naive bayes text classifier. Alpha is the pseudo
+// count added to every word in every class and must be greater than 0.
+func NewMultinomial(tokenizer Tokenizer, alpha float64) *Multinomial {
 	return &Multinomial{
+		Tokenizer:      tokenizer,
+		Alpha:          alpha,
+		vocabulary:     make(map[string]int),
 		vocabularySize: 0,
-		classSize:      make(map[string]int),
+		classSize:      make(map[string]float64),
 		classPriors:    make(map[string]float64),
-		wordCount:      make(map[string]map[string]int),
+		wordCount:      make(map[string][]float64),
 	}
 }
 
+// Construct a multinomial classifier which applies sublinear term frequency,
+// inverse document frequency and length normalization to all documents
+func NewTFIDFMultinomial(tokenizer Tokenizer, alpha float64) *Multinomial {
+	nba := NewMultinomial(tokenizer, alpha)
+	nba.SublinearTF = true
+	nba.IDF = true
+	nba.Normalize = true
+	return nba
+}
+
+// Construct a multinomial classifier which uses the hashing trick instead of
+// keeping a vocabulary. Words are mapped into a fixed number of buckets so
+// memory use does not grow with the number of distinct words, at the cost of
+// words sharing a bucket being indistinguishable. With signed hashing half of
+// the words are counted negatively so that collisions within a document
+// cancel out; the absolute value of each bucket is used as its count.
+func NewHashingMultinomial(tokenizer Tokenizer, alpha float64, buckets int, signed bool) *Multinomial {
+	nba := NewMultinomial(tokenizer, alpha)
+	nba.vocabulary = nil
+	nba.buckets = buckets
+	nba.signed = signed
+	return nba
+}
+
 func (nba *Multinomial) Fit(data map[string][]string) error {
+	nba.fitMu.Lock()
+	defer nba.fitMu.Unlock()
 	if len(data) < 1 {
 		return NoDataError
 	}
+	if nba.Alpha <= 0 {
+		return InvalidSmoothingError
+	}
+	if nba.vocabulary == nil && nba.buckets < 1 {
+		return InvalidBucketsError
+	}
 
-	// Need to keep track of these to calculate class priors
-	var totalDocs int = 0
-	docsForClass := make(map[string]int)
+	// Tokenizing is most of the work of fitting and does not touch the model,
+	// so it runs concurrently and without holding the lock
+	classes := make([]string, 0, len(data))
+	for class := range data {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	tokenized := make(map[string][][]string, len(data))
+	for _, class := range classes {
+		tokenized[class] = nba.tokenizeAll(data[class])
+	}
 
-	// Keep track of words that we have seen
-	vocabulary := make(map[string]bool)
+	nba.mu.Lock()
+	defer nba.mu.Unlock()
 
-	for class, docs := range data {
-		totalDocs += len(docs)
-		docsForClass[class] = len(docs)
+	// Counts from an earlier fit were weighted by that fit's document
+	// frequencies and cannot be combined with new ones, so IDF refits from scratch
+	if nba.IDF {
+		nba.resetCounts()
+	}
 
-		for _, doc := range docs {
-			nba.classSize[class] += len(doc)
+	// Learn words in class order so that vocabulary indices do not depend on map iteration
+	var totalDocs int = 0
+	var allDocs [][]string
+	for _, class := range classes {
+		totalDocs += len(tokenized[class])
+		for _, words := range tokenized[class] {
+			allDocs = append(allDocs, words)
+			nba.learnVocabulary(words)
+		}
+	}
 
-			for _, word := range words(doc) {
-				vocabulary[word] = true
+	if nba.IDF {
+		nba.fitIDF(allDocs)
+	}
 
-				if _, ok := nba.wordCount[class]; !ok {
-					nba.wordCount[class] = make(map[string]int)
-				}
-				nba.wordCount[class][word] += 1
-			}
-		}
+	for _, class := range classes {
+		nba.growCounts(class)
 	}
+	nba.countAll(classes, tokenized)
 
 	// Calculate class priors
-	for class, _ := range data {
-		nba.classPriors[class] = float64(docsForClass[class]) / float64(totalDocs)
+	for _, class := range classes {
+		nba.classPriors[class] = float64(len(data[class])) / float64(totalDocs)
 	}
+	nba.classes = sortedClasses(nba.classPriors)
 
-	nba.vocabularySize = len(vocabulary)
+	nba.vocabularySize = nba.countSeenFeatures()
 
 	return nil
 }
 
+// Tokenize documents concurrently
+func (nba *Multinomial) tokenizeAll(docs []string) [][]string {
+	words := make([][]string, len(docs))
+	parallel(len(docs), func(start, end int) {
+		for i := start; i < end; i++ {
+			words[i] = nba.Tokenizer.Tokenize(docs[i])
+		}
+	})
+	return words
+}
+
+// Add the features of tokenized documents to the counts of their classes.
+// Features are extracted concurrently and then added one document at a time
+// in class order, so the counts do not depend on the number of processors.
+func (nba *Multinomial) countAll(classes []string, tokenized map[string][][]string) {
+	type document struct {
+		class string
+		words []string
+	}
+	var docs []document
+	for _, class := range classes {
+		for _, words := range tokenized[class] {
+			docs = append(docs, document{class, words})
+		}
+	}
+
+	features := make([]map[int]float64, len(docs))
+	parallel(len(docs), func(start, end int) {
+		for i := start; i < end; i++ {
+			features[i] = nba.features(docs[i].words)
+		}
+	})
+
+	for i, doc := range docs {
+		nba.addCounts(doc.class, features[i], 1)
+	}
+}
+
 func (nba *Multinomial) Classify(doc string) (string, error) {
-	var bestClass string
-	var bestClassLogProbability float64 = -math.MaxFloat64
+	nba.mu.RLock()
+	defer nba.mu.RUnlock()
+	return classify(nba.classes, nba.classPriors, nba.logLikelihoods(doc))
+}
 
-	for class, classPrior := range nba.classPriors {
-		// Get the total class model for the point conditiond on this class
+// The log likelihood of a document conditioned on each class
+func (nba *Multinomial) logLikelihoods(doc string) map[string]float64 {
+	return nba.featureLogLikelihoods(nba.features(nba.Tokenizer.Tokenize(doc)))
+}
+
+// The log likelihood of a document's features conditioned on each class
+func (nba *Multinomial) featureLogLikelihoods(features map[int]float64) map[string]float64 {
+	indices := sortedFeatures(features)
+	logLikelihoods := make(map[string]float64, len(nba.classPriors))
+	for class := range nba.classPriors {
+		// Get the total class model for the document conditiond on this class
 		var logSum float64 = 0
-		for _, word := range words(doc) {
-			logSum += math.Log(float64(nba.wordCount[class][word]+1) / float64(nba.classSize[class]+nba.vocabularySize))
+		for _, i := range indices {
+			logSum += features[i] * nba.logLikelihood(class, i)
+		}
+		logLikelihoods[class] = logSum
+	}
+	return logLikelihoods
+}
+
+// The smoothed log probability of a feature given a class
+func (nba *Multinomial) logLikelihood(class string, feature int) float64 {
+	count := nba.Alpha
+	if counts := nba.wordCount[class]; feature >= 0 && feature < len(counts) {
+		count += counts[feature]
+	}
+	size := nba.classSize[class] + nba.Alpha*float64(nba.vocabularySize)
+	return math.Log(count / size)
+}
+
+// Keep track of words that we have seen
+func (nba *Multinomial) learnVocabulary(words []string) {
+	if nba.vocabulary == nil {
+		return
+	}
+	for _, word := range words {
+		if _, ok := nba.vocabulary[word]; !ok {
+			nba.vocabulary[word] = len(nba.vocabulary)
 		}
+	}
+}
+
+// Forget all fitted counts, the vocabulary and the class priors
+func (nba *Multinomial) resetCounts() {
+	if nba.vocabulary != nil {
+		nba.vocabulary = make(map[string]int)
+	}
+	nba.vocabularySize = 0
+	nba.classSize = make(map[string]float64)
+	nba.classPriors = make(map[string]float64)
+	nba.classes = nil
+	nba.wordCount = make(map[string][]float64)
+}
+
+// Make room for every feature in a class's count vector
+func (nba *Multinomial) growCounts(class string) {
+	counts := nba.wordCount[class]
+	if len(counts) < nba.numFeatures() {
+		counts = append(counts, make([]float64, nba.numFeatures()-len(counts))...)
+	}
+	nba.wordCount[class] = counts
+}
+
+// Add a document's features to the counts of a class, scaled by the document's weight
+func (nba *Multinomial) addCounts(class string, features map[int]float64, weight float64) {
+	counts := nba.wordCount[class]
+	for _, i := range sortedFeatures(features) {
+		if w := features[i]; i >= 0 {
+			counts[i] += weight * w
+			nba.classSize[class] += weight * w
+		}
+	}
+}
+
+// The length of the count vectors, either the vocabulary size or the number of buckets
+func (nba *Multinomial) numFeatures() int {
+	if nba.vocabulary == nil {
+		return nba.buckets
+	}
+	return len(nba.vocabulary)
+}
+
+// The number of features which have been seen in any class
+func (nba *Multinomial) countSeenFeatures() int {
+	seen := 0
+	for i := 0; i < nba.numFeatures(); i++ {
+		for _, counts := range nba.wordCount {
+			if i < len(counts) && counts[i] != 0 {
+				seen++
+				break
+			}
+		}
+	}
+	return seen
+}
+
+// Map a word to its feature index and sign. Words which are not in the
+// vocabulary have an index of -1.
+func (nba *Multinomial) feature(word string) (int, float64) {
+	if nba.vocabulary != nil {
+		if i, ok := nba.vocabulary[word]; ok {
+			return i, 1
+		}
+		return -1, 1
+	}
 
-		// Bayes theorem: P(c|e) = (P(e|c)P(c)) / P(e)
-		// We drop P(e) as it is constant
-		logProbability := logSum + math.Log(classPrior)
+	h := fnv.New64a()
+	h.Write([]byte(word))
+	sum := h.Sum64()
 
-		// Update current best class
-		if logProbability > bestClassLogProbability {
-			bestClassLogProbability = logProbability
-			bestClass = class
+	sign := 1.0
+	if nba.signed && sum>>63 == 1 {
+		sign = -1
+	}
+	return int(sum % uint64(nba.buckets)), sign
+}
+
+// Count the number of training documents containing each feature
+func (nba *Multinomial) fitIDF(docs [][]string) {
+	docFrequency := make([]int, nba.numFeatures())
+	for _, words := range docs {
+		seen := make(map[int]bool, len(words))
+		for _, word := range words {
+			if i, _ := nba.feature(word); i >= 0 && !seen[i] {
+				seen[i] = true
+				docFrequency[i]++
+			}
 		}
 	}
 
-	if bestClassLogProbability == -math.MaxFloat64 {
-		return "", NoClassificationError
+	nba.idfDocs = len(docs)
+	nba.idf = make([]float64, len(docFrequency))
+	for i, df := range docFrequency {
+		nba.idf[i] = nba.inverseDocumentFrequency(df)
 	}
+}
 
-	return bestClass, nil
+// Smoothed inverse document frequency, as if one extra document contained every word
+func (nba *Multinomial) inverseDocumentFrequency(df int) float64 {
+	return math.Log(float64(1+nba.idfDocs)/float64(1+df)) + 1
+}
+
+// Map each word of a document to its feature index and sign. Each distinct
+// word missing from the vocabulary is given its own negative index so that it
+// still contributes to the document's likelihood.
+func (nba *Multinomial) featureIndices(words []string) ([]int, []float64) {
+	indices := make([]int, len(words))
+	signs := make([]float64, len(words))
+	unseen := make(map[string]int)
+	for j, word := range words {
+		i, sign := nba.feature(word)
+		if i < 0 {
+			if _, ok := unseen[word]; !ok {
+				unseen[word] = -len(unseen) - 1
+			}
+			i = unseen[word]
+		}
+		indices[j] = i
+		signs[j] = sign
+	}
+	return indices, signs
+}
+
+// Turn the words of a document into weighted counts indexed by feature
+func (nba *Multinomial) features(words []string) map[int]float64 {
+	features := make(map[int]float64)
+	indices, signs := nba.featureIndices(words)
+	for j, i := range indices {
+		features[i] += signs[j]
+	}
+
+	for i, count := range features {
+		features[i] = math.Abs(count)
+		if features[i] == 0 {
+			delete(features, i)
+		}
+	}
+
+	if nba.SublinearTF {
+		for i, count := range features {
+			features[i] = math.Log(1 + count)
+		}
+	}
+
+	if nba.IDF {
+		for i := range features {
+			idf := nba.inverseDocumentFrequency(0)
+			if i >= 0 && i < len(nba.idf) {
+				idf = nba.idf[i]
+			}
+			features[i] *= idf
+		}
+	}
+
+	if nba.Normalize {
+		var norm float64
+		for _, i := range sortedFeatures(features) {
+			norm += features[i] * features[i]
+		}
+		norm = math.Sqrt(norm)
+		if norm > 0 {
+			for i := range features {
+				features[i] /= norm
+			}
+		}
+	}
+
+	return features
+}
+
+// The feature indices of a document in increasing order. Summing over them in
+// this order makes scoring a document give exactly the same result every time.
+func sortedFeatures(features map[int]float64) []int {
+	indices := make([]int, 0, len(features))
+	for i := range features {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	return indices
 }
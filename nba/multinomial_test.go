@@ -0,0 +1,396 @@
+package nba
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const newsgroupsDir = "example_multinomial/20news-bydate"
+
+// Read a directory containing one sub directory of documents per class
+func readNewsgroups(t *testing.T, dirName string) map[string][]string {
+	children, err := ioutil.ReadDir(dirName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := make(map[string][]string)
+	for _, child := range children {
+		if strings.HasPrefix(child.Name(), ".") || !child.IsDir() {
+			continue
+		}
+		docFiles, err := ioutil.ReadDir(filepath.Join(dirName, child.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range docFiles {
+			content, err := ioutil.ReadFile(filepath.Join(dirName, child.Name(), f.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			data[child.Name()] = append(data[child.Name()], string(content))
+		}
+	}
+	return data
+}
+
+func accuracy(classifier *Multinomial, test map[string][]string) float64 {
+	total := 0
+	correct := 0
+	for class, docs := range test {
+		for _, doc := range docs {
+			total++
+			if result, err := classifier.Classify(doc); err == nil && result == class {
+				correct++
+			}
+		}
+	}
+	return float64(correct) / float64(total)
+}
+
+func TestMultinomialClassify(t *testing.T) {
+	classifier := NewMultinomial(NewWordTokenizer(), 1)
+	err := classifier.Fit(map[string][]string{
+		"sports":  {"the ball hit the goal post", "a great goal in the final minute"},
+		"finance": {"the stock market fell", "interest rates and the stock price"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if class, err := classifier.Classify("Who scored the goal?"); class != "sports" {
+		t.Errorf("Failed to classify sports: class = %v, error = %v", class, err)
+	}
+	if class, err := classifier.Classify("Stock prices and interest"); class != "finance" {
+		t.Errorf("Failed to classify finance: class = %v, error = %v", class, err)
+	}
+}
+
+func TestMultinomialClassSizeCountsWords(t *testing.T) {
+	classifier := NewMultinomial(NewWordTokenizer(), 1)
+	if err := classifier.Fit(map[string][]string{"a": {"one two,  three"}}); err != nil {
+		t.Fatal(err)
+	}
+	if classifier.classSize["a"] != 3 {
+		t.Errorf("Expected class size of 3 words, got %v", classifier.classSize["a"])
+	}
+}
+
+func TestMultinomialFeatures(t *testing.T) {
+	classifier := NewTFIDFMultinomial(NewWordTokenizer(), 1)
+	if err := classifier.Fit(map[string][]string{"a": {"x y", "x"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	features := classifier.features([]string{"x", "y", "z"})
+	var norm float64
+	for _, weight := range features {
+		norm += weight * weight
+	}
+	if math.Abs(norm-1) > 1e-9 {
+		t.Errorf("Expected unit length features, got squared norm %v", norm)
+	}
+
+	// z is unseen so it gets the largest idf, x is in every document so it gets the lowest
+	x, y, z := classifier.vocabulary["x"], classifier.vocabulary["y"], -1
+	if !(features[z] > features[y] && features[x] < features[y]) {
+		t.Errorf("Unexpected tf-idf weights: %v", features)
+	}
+}
+
+func TestMultinomialIDFRefit(t *testing.T) {
+	refit := NewTFIDFMultinomial(NewWordTokenizer(), 1)
+	if err := refit.Fit(map[string][]string{"a": {"x y", "x"}, "b": {"z"}}); err != nil {
+		t.Fatal(err)
+	}
+	second := map[string][]string{"a": {"x w"}, "c": {"w v", "v"}}
+	if err := refit.Fit(second); err != nil {
+		t.Fatal(err)
+	}
+	fresh := NewTFIDFMultinomial(NewWordTokenizer(), 1)
+	if err := fresh.Fit(second); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(refit.vocabulary, fresh.vocabulary) || !reflect.DeepEqual(refit.idf, fresh.idf) {
+		t.Errorf("Refit vocabulary %v and idf %v differ from %v and %v", refit.vocabulary, refit.idf, fresh.vocabulary, fresh.idf)
+	}
+	if !reflect.DeepEqual(refit.wordCount, fresh.wordCount) || !reflect.DeepEqual(refit.classPriors, fresh.classPriors) {
+		t.Errorf("Refit counts %v differ from %v", refit.wordCount, fresh.wordCount)
+	}
+}
+
+func TestHashingMultinomial(t *testing.T) {
+	classifier := NewHashingMultinomial(NewWordTokenizer(), 1, 16, true)
+	err := classifier.Fit(map[string][]string{
+		"sports":  {"the ball hit the goal post", "a great goal in the final minute"},
+		"finance": {"the stock market fell", "interest rates and the stock price"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for class, counts := range classifier.wordCount {
+		if len(counts) != 16 {
+			t.Errorf("Expected 16 buckets for class %v, got %v", class, len(counts))
+		}
+	}
+	if class, err := classifier.Classify("stock market"); class != "finance" {
+		t.Errorf("Failed to classify finance: class = %v, error = %v", class, err)
+	}
+
+	classifier = NewHashingMultinomial(NewWordTokenizer(), 1, 0, false)
+	if err := classifier.Fit(map[string][]string{"a": {"doc"}}); err != InvalidBucketsError {
+		t.Errorf("Expected InvalidBucketsError, got %v", err)
+	}
+}
+
+func TestMultinomialInvalidSmoothing(t *testing.T) {
+	classifier := NewMultinomial(NewWordTokenizer(), 0)
+	if err := classifier.Fit(map[string][]string{"a": {"doc"}}); err != InvalidSmoothingError {
+		t.Errorf("Expected InvalidSmoothingError, got %v", err)
+	}
+}
+
+func TestMultinomialParallelFit(t *testing.T) {
+	data := make(map[string][]string)
+	for class, docs := range selectionData {
+		for i := 0; i < 50; i++ {
+			data[class] = append(data[class], docs...)
+		}
+	}
+
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	for _, newClassifier := range []func(Tokenizer, float64) *Multinomial{NewMultinomial, NewTFIDFMultinomial} {
+		runtime.GOMAXPROCS(1)
+		sequential := newClassifier(NewWordTokenizer(), 1)
+		if err := sequential.Fit(data); err != nil {
+			t.Fatal(err)
+		}
+
+		runtime.GOMAXPROCS(4)
+		parallel := newClassifier(NewWordTokenizer(), 1)
+		if err := parallel.Fit(data); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(sequential.vocabulary, parallel.vocabulary) {
+			t.Errorf("Vocabulary differs: %v != %v", sequential.vocabulary, parallel.vocabulary)
+		}
+		if !reflect.DeepEqual(sequential.wordCount, parallel.wordCount) || !reflect.DeepEqual(sequential.classSize, parallel.classSize) {
+			t.Errorf("Word counts differ: %v != %v", sequential.wordCount, parallel.wordCount)
+		}
+	}
+}
+
+// Run with -race to check that classification is safe while fitting
+func TestMultinomialClassifyWhileFitting(t *testing.T) {
+	classifier := NewTFIDFMultinomial(NewWordTokenizer(), 1)
+	if err := classifier.Fit(selectionData); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if class, err := classifier.Classify("a late goal"); err != nil || class != "sports" {
+					t.Errorf("Failed to classify while fitting: class = %v, error = %v", class, err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if err := classifier.Fit(selectionData); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
+
+// A DocumentStream over in memory documents, read in class order
+type sliceStream struct {
+	classes []string
+	docs    []string
+}
+
+func newSliceStream(data map[string][]string) *sliceStream {
+	var classes []string
+	for class := range data {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	s := &sliceStream{}
+	for _, class := range classes {
+		for _, doc := range data[class] {
+			s.classes = append(s.classes, class)
+			s.docs = append(s.docs, doc)
+		}
+	}
+	return s
+}
+
+func (s *sliceStream) Next() (string, string, error) {
+	if len(s.docs) == 0 {
+		return "", "", io.EOF
+	}
+	class, doc := s.classes[0], s.docs[0]
+	s.classes, s.docs = s.classes[1:], s.docs[1:]
+	return class, doc, nil
+}
+
+func TestMultinomialFitStream(t *testing.T) {
+	fit := NewMultinomial(NewWordTokenizer(), 1)
+	if err := fit.Fit(selectionData); err != nil {
+		t.Fatal(err)
+	}
+	streamed := NewMultinomial(NewWordTokenizer(), 1)
+	if err := streamed.FitStream(newSliceStream(selectionData)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fit.vocabulary, streamed.vocabulary) || !reflect.DeepEqual(fit.wordCount, streamed.wordCount) {
+		t.Errorf("Streamed counts %v differ from %v", streamed.wordCount, fit.wordCount)
+	}
+	if !reflect.DeepEqual(fit.classPriors, streamed.classPriors) || fit.vocabularySize != streamed.vocabularySize {
+		t.Errorf("Streamed priors %v differ from %v", streamed.classPriors, fit.classPriors)
+	}
+
+	if err := NewMultinomial(NewWordTokenizer(), 1).FitStream(newSliceStream(nil)); err != NoDataError {
+		t.Errorf("Expected NoDataError, got %v", err)
+	}
+	if err := NewTFIDFMultinomial(NewWordTokenizer(), 1).FitStream(newSliceStream(selectionData)); err != StreamingIDFError {
+		t.Errorf("Expected StreamingIDFError, got %v", err)
+	}
+}
+
+// A stream which calls check before every document and fails once n documents have been read
+type failingStream struct {
+	DocumentStream
+	n     int
+	check func()
+}
+
+func (s *failingStream) Next() (string, string, error) {
+	s.check()
+	if s.n == 0 {
+		return "", "", errors.New("stream failed")
+	}
+	s.n--
+	return s.DocumentStream.Next()
+}
+
+func TestMultinomialFitStreamIsAtomic(t *testing.T) {
+	classifier := NewMultinomial(NewWordTokenizer(), 1)
+	if err := classifier.Fit(map[string][]string{"old": {"old words"}}); err != nil {
+		t.Fatal(err)
+	}
+	before := classifier.copyFitted()
+
+	// The model does not change while documents are read, nor after the stream fails
+	unchanged := func() {
+		classifier.mu.RLock()
+		defer classifier.mu.RUnlock()
+		if !reflect.DeepEqual(classifier.wordCount, before.wordCount) || !reflect.DeepEqual(classifier.classPriors, before.classPriors) {
+			t.Fatalf("Model changed while streaming: %v", classifier.wordCount)
+		}
+	}
+	stream := &failingStream{DocumentStream: newSliceStream(selectionData), n: 3, check: unchanged}
+	if err := classifier.FitStream(stream); err == nil {
+		t.Fatal("Expected the stream error")
+	}
+	unchanged()
+
+	stream = &failingStream{DocumentStream: newSliceStream(selectionData), n: -1, check: unchanged}
+	if err := classifier.FitStream(stream); err != nil {
+		t.Fatal(err)
+	}
+	for class, counts := range classifier.wordCount {
+		if len(counts) != len(classifier.vocabulary) {
+			t.Errorf("Counts of %v have length %v, expected %v", class, len(counts), len(classifier.vocabulary))
+		}
+	}
+}
+
+func TestMultinomialFitDuringStream(t *testing.T) {
+	other := map[string][]string{"sports": {"a late winner"}, "weather": {"rain all week"}}
+	classifier := NewMultinomial(NewWordTokenizer(), 1)
+
+	// Start a fit while the stream is read, it waits for the stream and is applied after it
+	done := make(chan error, 1)
+	started := false
+	waiting := func() {
+		if !started {
+			started = true
+			go func() { done <- classifier.Fit(other) }()
+		}
+		select {
+		case <-done:
+			t.Fatal("Fit finished while the stream was read")
+		default:
+		}
+	}
+	stream := &failingStream{DocumentStream: newSliceStream(selectionData), n: -1, check: waiting}
+	if err := classifier.FitStream(stream); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	expected := NewMultinomial(NewWordTokenizer(), 1)
+	if err := expected.FitStream(newSliceStream(selectionData)); err != nil {
+		t.Fatal(err)
+	}
+	if err := expected.Fit(other); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(classifier.wordCount, expected.wordCount) || !reflect.DeepEqual(classifier.classPriors, expected.classPriors) {
+		t.Errorf("Fit during the stream was lost: %v != %v", classifier.wordCount, expected.wordCount)
+	}
+}
+
+// Pins the accuracy on the 20 newsgroups test set. Laplace smoothing scores
+// 77.67% now that class sizes are counted in words, it scored 77.7% when they
+// were counted in bytes.
+func TestMultinomialNewsgroupsAccuracy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping 20 newsgroups regression test in short mode")
+	}
+
+	training := readNewsgroups(t, filepath.Join(newsgroupsDir, "20news-bydate-train"))
+	test := readNewsgroups(t, filepath.Join(newsgroupsDir, "20news-bydate-test"))
+
+	for _, c := range []struct {
+		name       string
+		classifier *Multinomial
+		expected   float64
+	}{
+		{"laplace", NewMultinomial(NewWordTokenizer(), 1), 0.7765},
+		{"lidstone", NewMultinomial(NewWordTokenizer(), 0.1), 0.803},
+		{"tf-idf", NewTFIDFMultinomial(NewWordTokenizer(), 0.1), 0.833},
+		{"hashing", NewHashingMultinomial(NewWordTokenizer(), 0.1, 1<<18, true), 0.799},
+	} {
+		if err := c.classifier.Fit(training); err != nil {
+			t.Fatal(err)
+		}
+		if acc := accuracy(c.classifier, test); acc < c.expected {
+			t.Errorf("Accuracy of %v classifier dropped to %.4f, expected at least %v", c.name, acc, c.expected)
+		} else {
+			t.Logf("Accuracy of %v classifier: %.4f", c.name, acc)
+		}
+	}
+}
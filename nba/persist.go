@@ -0,0 +1,543 @@
+package nba
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// Encoding used when saving and loading models
+type Format int
+
+const (
+	// Human readable JSON
+	JSON Format = iota
+
+	// Compact binary encoding using encoding/gob
+	Binary
+)
+
+// Version of the saved model layout, increased whenever the layout changes
+// in a way older versions of this package cannot read. Version 2 added the
+// Gaussian variance floor, priors and kernel densities and the categorical and
+// mixed models.
+const modelVersion = 2
+
+type (
+	// Saved form of the tokenizers provided by this package
+	tokenizerModel struct {
+		Type string
+
+		// WordTokenizer configuration
+		Lowercase bool     `json:",omitempty"`
+		StopWords []string `json:",omitempty"`
+		Stem      bool     `json:",omitempty"`
+		MinN      int      `json:",omitempty"`
+		MaxN      int      `json:",omitempty"`
+
+		// VocabularyTokenizer configuration
+		Vocabulary []string        `json:",omitempty"`
+		Tokenizer  *tokenizerModel `json:",omitempty"`
+	}
+
+	// Saved form of a Distribution. Type is empty for gaussians so that models
+	// saved before kernel densities existed still load.
+	distributionModel struct {
+		Type      string    `json:",omitempty"`
+		Mean      float64   `json:",omitempty"`
+		Sigma     float64   `json:",omitempty"`
+		Samples   []float64 `json:",omitempty"`
+		Weights   []float64 `json:",omitempty"`
+		Bandwidth float64   `json:",omitempty"`
+	}
+
+	gaussianModel struct {
+		Version              int
+		VarEpsilon           float64
+		VarSmoothing         float64
+		Priors               map[string]float64 `json:",omitempty"`
+		FeatureDistributions []DistributionType `json:",omitempty"`
+		Bandwidths           []float64          `json:",omitempty"`
+		Dimensionality       int
+		ClassPriors          map[string]float64
+		ClassModel           map[string][]distributionModel
+	}
+
+	multinomialModel struct {
+		Version     int
+		Tokenizer   *tokenizerModel
+		Alpha       float64
+		SublinearTF bool
+		IDF         bool
+		Normalize   bool
+		Buckets     int
+		Signed      bool
+
+		// Words ordered by their feature index, empty for hashing models
+		Vocabulary     []string
+		VocabularySize int
+		ClassSize      map[string]float64
+		ClassPriors    map[string]float64
+		WordCount      map[string][]float64
+		IDFWeights     []float64
+		IDFDocs        int
+	}
+
+	categoricalModel struct {
+		Version        int
+		Alpha          float64
+		Dimensionality int
+		ClassPriors    map[string]float64
+		ClassCount     map[string]float64
+		ValueCount     map[string][]map[string]float64
+		Values         [][]string
+	}
+
+	// Saved form of a FeatureGroup, text group tokenizers are saved with their model
+	featureGroupModel struct {
+		Name           string
+		Kind           FeatureKind
+		Dimensionality int                `json:",omitempty"`
+		Distributions  []DistributionType `json:",omitempty"`
+		Alpha          float64            `json:",omitempty"`
+	}
+
+	mixedModel struct {
+		Version     int
+		Schema      []featureGroupModel
+		ClassPriors map[string]float64
+		Numeric     map[string]*gaussianModel    `json:",omitempty"`
+		Categorical map[string]*categoricalModel `json:",omitempty"`
+		Text        map[string]*multinomialModel `json:",omitempty"`
+	}
+)
+
+// Write the model's parameters in the given format
+func (nba *Gaussian) Save(w io.Writer, format Format) error {
+	nba.mu.RLock()
+	defer nba.mu.RUnlock()
+	m, err := nba.save()
+	if err != nil {
+		return err
+	}
+	return encode(w, format, m)
+}
+
+// Read a model written by Gaussian.Save
+func LoadGaussian(r io.Reader, format Format) (*Gaussian, error) {
+	var m gaussianModel
+	if err := decode(r, format, &m); err != nil {
+		return nil, err
+	}
+	return loadGaussian(&m)
+}
+
+func (nba *Gaussian) save() (*gaussianModel, error) {
+	classModel := make(map[string][]distributionModel, len(nba.classModel))
+	for class, distributions := range nba.classModel {
+		classModel[class] = make([]distributionModel, len(distributions))
+		for i, d := range distributions {
+			m, err := saveDistribution(d)
+			if err != nil {
+				return nil, err
+			}
+			classModel[class][i] = m
+		}
+	}
+
+	return &gaussianModel{
+		Version:              modelVersion,
+		VarEpsilon:           nba.VarEpsilon,
+		VarSmoothing:         nba.VarSmoothing,
+		Priors:               nba.Priors,
+		FeatureDistributions: nba.FeatureDistributions,
+		Bandwidths:           nba.Bandwidths,
+		Dimensionality:       nba.dimensionality,
+		ClassPriors:          nba.classPriors,
+		ClassModel:           classModel,
+	}, nil
+}
+
+func loadGaussian(m *gaussianModel) (*Gaussian, error) {
+	if m.Version < 1 || m.Version > modelVersion {
+		return nil, UnsupportedVersionError
+	}
+
+	nba := NewGaussian(m.Dimensionality)
+	// Version 1 models were fit with the default variance floor and do not save it
+	if m.Version > 1 {
+		nba.VarEpsilon = m.VarEpsilon
+	}
+	nba.VarSmoothing = m.VarSmoothing
+	nba.Priors = m.Priors
+	nba.FeatureDistributions = m.FeatureDistributions
+	nba.Bandwidths = m.Bandwidths
+	if m.ClassPriors != nil {
+		nba.classPriors = m.ClassPriors
+	}
+	nba.classes = sortedClasses(nba.classPriors)
+	for class, distributions := range m.ClassModel {
+		nba.classModel[class] = make([]Distribution, len(distributions))
+		for i, d := range distributions {
+			distribution, err := loadDistribution(d)
+			if err != nil {
+				return nil, err
+			}
+			nba.classModel[class][i] = distribution
+		}
+	}
+	return nba, nil
+}
+
+func saveDistribution(d Distribution) (distributionModel, error) {
+	switch d := d.(type) {
+	case GuassianDistribution:
+		return distributionModel{Mean: d.Mean, Sigma: d.Sigma}, nil
+	case *KernelDensity:
+		return distributionModel{Type: "kernel", Samples: d.Samples, Weights: d.Weights, Bandwidth: d.Bandwidth}, nil
+	}
+	return distributionModel{}, UnsupportedDistributionError
+}
+
+func loadDistribution(m distributionModel) (Distribution, error) {
+	switch m.Type {
+	case "":
+		return GuassianDistribution{m.Mean, m.Sigma}, nil
+	case "kernel":
+		return &KernelDensity{Samples: m.Samples, Weights: m.Weights, Bandwidth: m.Bandwidth}, nil
+	}
+	return nil, UnsupportedDistributionError
+}
+
+// Write the model's parameters and tokenizer configuration in the given format.
+// Only the tokenizers provided by this package can be saved.
+func (nba *Multinomial) Save(w io.Writer, format Format) error {
+	nba.mu.RLock()
+	defer nba.mu.RUnlock()
+	m, err := nba.save()
+	if err != nil {
+		return err
+	}
+	return encode(w, format, m)
+}
+
+// Read a model written by Multinomial.Save
+func LoadMultinomial(r io.Reader, format Format) (*Multinomial, error) {
+	var m multinomialModel
+	if err := decode(r, format, &m); err != nil {
+		return nil, err
+	}
+	return loadMultinomial(&m)
+}
+
+func (nba *Multinomial) save() (*multinomialModel, error) {
+	tokenizer, err := saveTokenizer(nba.Tokenizer)
+	if err != nil {
+		return nil, err
+	}
+
+	var vocabulary []string
+	if nba.vocabulary != nil {
+		vocabulary = make([]string, len(nba.vocabulary))
+		for word, i := range nba.vocabulary {
+			vocabulary[i] = word
+		}
+	}
+
+	return &multinomialModel{
+		Version:        modelVersion,
+		Tokenizer:      tokenizer,
+		Alpha:          nba.Alpha,
+		SublinearTF:    nba.SublinearTF,
+		IDF:            nba.IDF,
+		Normalize:      nba.Normalize,
+		Buckets:        nba.buckets,
+		Signed:         nba.signed,
+		Vocabulary:     vocabulary,
+		VocabularySize: nba.vocabularySize,
+		ClassSize:      nba.classSize,
+		ClassPriors:    nba.classPriors,
+		WordCount:      nba.wordCount,
+		IDFWeights:     nba.idf,
+		IDFDocs:        nba.idfDocs,
+	}, nil
+}
+
+func loadMultinomial(m *multinomialModel) (*Multinomial, error) {
+	if m.Version < 1 || m.Version > modelVersion {
+		return nil, UnsupportedVersionError
+	}
+
+	tokenizer, err := loadTokenizer(m.Tokenizer)
+	if err != nil {
+		return nil, err
+	}
+
+	var nba *Multinomial
+	if m.Buckets > 0 {
+		nba = NewHashingMultinomial(tokenizer, m.Alpha, m.Buckets, m.Signed)
+	} else {
+		nba = NewMultinomial(tokenizer, m.Alpha)
+		for i, word := range m.Vocabulary {
+			nba.vocabulary[word] = i
+		}
+	}
+	nba.SublinearTF = m.SublinearTF
+	nba.IDF = m.IDF
+	nba.Normalize = m.Normalize
+	nba.vocabularySize = m.VocabularySize
+	nba.idf = m.IDFWeights
+	nba.idfDocs = m.IDFDocs
+	if m.ClassSize != nil {
+		nba.classSize = m.ClassSize
+	}
+	if m.ClassPriors != nil {
+		nba.classPriors = m.ClassPriors
+	}
+	nba.classes = sortedClasses(nba.classPriors)
+	if m.WordCount != nil {
+		nba.wordCount = m.WordCount
+	}
+	return nba, nil
+}
+
+// Write the model's parameters in the given format
+func (nba *Categorical) Save(w io.Writer, format Format) error {
+	nba.mu.RLock()
+	defer nba.mu.RUnlock()
+	return encode(w, format, nba.save())
+}
+
+// Read a model written by Categorical.Save
+func LoadCategorical(r io.Reader, format Format) (*Categorical, error) {
+	var m categoricalModel
+	if err := decode(r, format, &m); err != nil {
+		return nil, err
+	}
+	return loadCategorical(&m)
+}
+
+func (nba *Categorical) save() *categoricalModel {
+	values := make([][]string, len(nba.values))
+	for i, set := range nba.values {
+		values[i] = sortedKeys(set)
+	}
+	return &categoricalModel{
+		Version:        modelVersion,
+		Alpha:          nba.Alpha,
+		Dimensionality: nba.dimensionality,
+		ClassPriors:    nba.classPriors,
+		ClassCount:     nba.classCount,
+		ValueCount:     nba.valueCount,
+		Values:         values,
+	}
+}
+
+func loadCategorical(m *categoricalModel) (*Categorical, error) {
+	if m.Version < 1 || m.Version > modelVersion {
+		return nil, UnsupportedVersionError
+	}
+	if len(m.Values) > m.Dimensionality {
+		return nil, WrongDimensionError
+	}
+
+	nba := NewCategorical(m.Dimensionality, m.Alpha)
+	for i, values := range m.Values {
+		nba.values[i] = wordSet(values...)
+	}
+	if m.ClassPriors != nil {
+		nba.classPriors = m.ClassPriors
+	}
+	nba.classes = sortedClasses(nba.classPriors)
+	if m.ClassCount != nil {
+		nba.classCount = m.ClassCount
+	}
+	if m.ValueCount != nil {
+		nba.valueCount = m.ValueCount
+	}
+	return nba, nil
+}
+
+// Write the schema and the parameters of every feature group in the given format.
+// Only the tokenizers provided by this package can be saved.
+func (nba *Mixed) Save(w io.Writer, format Format) error {
+	nba.mu.RLock()
+	defer nba.mu.RUnlock()
+	m := &mixedModel{
+		Version:     modelVersion,
+		ClassPriors: nba.classPriors,
+		Numeric:     make(map[string]*gaussianModel),
+		Categorical: make(map[string]*categoricalModel),
+		Text:        make(map[string]*multinomialModel),
+	}
+
+	for _, group := range nba.schema {
+		m.Schema = append(m.Schema, featureGroupModel{
+			Name:           group.Name,
+			Kind:           group.Kind,
+			Dimensionality: group.Dimensionality,
+			Distributions:  group.Distributions,
+			Alpha:          group.Alpha,
+		})
+
+		var err error
+		switch group.Kind {
+		case NumericFeatures:
+			m.Numeric[group.Name], err = nba.numeric[group.Name].save()
+		case CategoricalFeatures:
+			m.Categorical[group.Name] = nba.categorical[group.Name].save()
+		case TextFeatures:
+			m.Text[group.Name], err = nba.text[group.Name].save()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return encode(w, format, m)
+}
+
+// Read a model written by Mixed.Save
+func LoadMixed(r io.Reader, format Format) (*Mixed, error) {
+	var m mixedModel
+	if err := decode(r, format, &m); err != nil {
+		return nil, err
+	}
+	if m.Version < 1 || m.Version > modelVersion {
+		return nil, UnsupportedVersionError
+	}
+
+	var schema []FeatureGroup
+	for _, g := range m.Schema {
+		schema = append(schema, FeatureGroup{
+			Name:           g.Name,
+			Kind:           g.Kind,
+			Dimensionality: g.Dimensionality,
+			Distributions:  g.Distributions,
+			Alpha:          g.Alpha,
+		})
+	}
+	// Text groups get their tokenizers from their saved models below
+	nba := newMixed(schema)
+	if m.ClassPriors != nil {
+		nba.classPriors = m.ClassPriors
+	}
+	nba.classes = sortedClasses(nba.classPriors)
+
+	for _, group := range schema {
+		var err error
+		switch group.Kind {
+		case NumericFeatures:
+			if gm, ok := m.Numeric[group.Name]; ok {
+				nba.numeric[group.Name], err = loadGaussian(gm)
+			}
+		case CategoricalFeatures:
+			if cm, ok := m.Categorical[group.Name]; ok {
+				nba.categorical[group.Name], err = loadCategorical(cm)
+			}
+		case TextFeatures:
+			if tm, ok := m.Text[group.Name]; ok {
+				nba.text[group.Name], err = loadMultinomial(tm)
+			}
+		default:
+			err = UnknownFeatureKindError
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Text groups get their tokenizer back from the saved multinomial model
+	for i, group := range nba.schema {
+		if group.Kind == TextFeatures {
+			nba.schema[i].Tokenizer = nba.text[group.Name].Tokenizer
+		}
+	}
+	if err := validateSchema(nba.schema); err != nil {
+		return nil, err
+	}
+	return nba, nil
+}
+
+func saveTokenizer(tokenizer Tokenizer) (*tokenizerModel, error) {
+	switch t := tokenizer.(type) {
+	case *WordTokenizer:
+		return &tokenizerModel{
+			Type:      "word",
+			Lowercase: t.Lowercase,
+			StopWords: sortedKeys(t.StopWords),
+			Stem:      t.Stem,
+			MinN:      t.MinN,
+			MaxN:      t.MaxN,
+		}, nil
+	case *VocabularyTokenizer:
+		inner, err := saveTokenizer(t.Tokenizer)
+		if err != nil {
+			return nil, err
+		}
+		return &tokenizerModel{
+			Type:       "vocabulary",
+			Vocabulary: sortedKeys(t.Vocabulary),
+			Tokenizer:  inner,
+		}, nil
+	}
+	return nil, UnsupportedTokenizerError
+}
+
+func loadTokenizer(m *tokenizerModel) (Tokenizer, error) {
+	if m == nil {
+		return nil, UnsupportedTokenizerError
+	}
+	switch m.Type {
+	case "word":
+		t := &WordTokenizer{
+			Lowercase: m.Lowercase,
+			Stem:      m.Stem,
+			MinN:      m.MinN,
+			MaxN:      m.MaxN,
+		}
+		if len(m.StopWords) > 0 {
+			t.StopWords = wordSet(m.StopWords...)
+		}
+		return t, nil
+	case "vocabulary":
+		inner, err := loadTokenizer(m.Tokenizer)
+		if err != nil {
+			return nil, err
+		}
+		return &VocabularyTokenizer{Tokenizer: inner, Vocabulary: wordSet(m.Vocabulary...)}, nil
+	}
+	return nil, UnsupportedTokenizerError
+}
+
+func sortedKeys(set map[string]bool) []string {
+	var keys []string
+	for key, ok := range set {
+		if ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func encode(w io.Writer, format Format, v interface{}) error {
+	switch format {
+	case JSON:
+		return json.NewEncoder(w).Encode(v)
+	case Binary:
+		return gob.NewEncoder(w).Encode(v)
+	}
+	return UnknownFormatError
+}
+
+func decode(r io.Reader, format Format, v interface{}) error {
+	switch format {
+	case JSON:
+		return json.NewDecoder(r).Decode(v)
+	case Binary:
+		return gob.NewDecoder(r).Decode(v)
+	}
+	return UnknownFormatError
+}
@@ -0,0 +1,128 @@
+package nba
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var persistDocs = []string{
+	"the goal was great",
+	"stock prices are up",
+	"an unexpected word appears",
+	"",
+}
+
+func TestGaussianSaveLoad(t *testing.T) {
+	classifier := NewGaussian(2)
+	classifier.VarEpsilon = 0
+	classifier.VarSmoothing = 1e-9
+	err := classifier.Fit(map[string][]Point{
+		"low":  {{0, 0}, {1, 1}, {0, 1}},
+		"high": {{9, 9}, {10, 10}, {9, 10}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []Format{JSON, Binary} {
+		var buf bytes.Buffer
+		if err := classifier.Save(&buf, format); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGaussian(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.VarEpsilon != classifier.VarEpsilon || loaded.VarSmoothing != classifier.VarSmoothing {
+			t.Errorf("Format %v changed the variance floor: %v, %v", format, loaded.VarEpsilon, loaded.VarSmoothing)
+		}
+
+		for _, p := range []Point{{0, 0}, {5, 5}, {1, 9}, {10, 10}} {
+			expected, _ := classifier.Explain(p)
+			actual, _ := loaded.Explain(p)
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("Format %v changed prediction for %v: %v != %v", format, p, actual, expected)
+			}
+		}
+	}
+}
+
+func TestGaussianLoadVersion1(t *testing.T) {
+	v1 := `{"Version": 1, "Dimensionality": 1, "ClassPriors": {"low": 0.5, "high": 0.5},
+		"ClassModel": {"low": [{"Mean": 0, "Sigma": 1}], "high": [{"Mean": 10, "Sigma": 1}]}}`
+	loaded, err := LoadGaussian(strings.NewReader(v1), JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.VarEpsilon != defaultVarEpsilon || loaded.VarSmoothing != 0 {
+		t.Errorf("Expected the default variance floor, got VarEpsilon = %v, VarSmoothing = %v", loaded.VarEpsilon, loaded.VarSmoothing)
+	}
+	if class, err := loaded.Classify(Point{9}); class != "high" {
+		t.Errorf("Failed to classify with a version 1 model: class = %v, error = %v", class, err)
+	}
+}
+
+func TestMultinomialSaveLoad(t *testing.T) {
+	tokenizer := &WordTokenizer{Lowercase: true, StopWords: EnglishStopWords, Stem: true, MinN: 1, MaxN: 2}
+	selected, err := SelectFeatures(tokenizer, selectionData, ChiSquared, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, classifier := range []*Multinomial{
+		NewMultinomial(NewWordTokenizer(), 0.5),
+		NewTFIDFMultinomial(tokenizer, 0.1),
+		NewHashingMultinomial(tokenizer, 1, 8, true),
+		NewMultinomial(NewVocabularyTokenizer(tokenizer, selected), 1),
+	} {
+		if err := classifier.Fit(selectionData); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, format := range []Format{JSON, Binary} {
+			var buf bytes.Buffer
+			if err := classifier.Save(&buf, format); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := LoadMultinomial(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(classifier.Tokenizer, loaded.Tokenizer) {
+				t.Errorf("Format %v changed tokenizer: %v != %v", format, loaded.Tokenizer, classifier.Tokenizer)
+			}
+			for _, doc := range persistDocs {
+				expected, _ := classifier.Explain(doc)
+				actual, _ := loaded.Explain(doc)
+				if !reflect.DeepEqual(expected, actual) {
+					t.Errorf("Format %v changed prediction for %q: %v != %v", format, doc, actual, expected)
+				}
+			}
+		}
+	}
+}
+
+type upperTokenizer struct{}
+
+func (upperTokenizer) Tokenize(doc string) []string {
+	return strings.Fields(strings.ToUpper(doc))
+}
+
+func TestLoadErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewMultinomial(upperTokenizer{}, 1).Save(&buf, JSON); err != UnsupportedTokenizerError {
+		t.Errorf("Expected UnsupportedTokenizerError, got %v", err)
+	}
+
+	if _, err := LoadGaussian(strings.NewReader(`{"Version": 99}`), JSON); err != UnsupportedVersionError {
+		t.Errorf("Expected UnsupportedVersionError, got %v", err)
+	}
+
+	if err := NewGaussian(1).Save(&buf, Format(42)); err != UnknownFormatError {
+		t.Errorf("Expected UnknownFormatError, got %v", err)
+	}
+}
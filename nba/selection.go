@@ -0,0 +1,140 @@
+package nba
+
+import (
+	"math"
+	"sort"
+)
+
+type (
+	// Statistic used to measure the dependence between a word and the class labels
+	SelectionCriterion int
+
+	ScoredWord struct {
+		Word  string
+		Score float64
+	}
+
+	// VocabularyTokenizer drops every word produced by the wrapped tokenizer
+	// which is not part of a fixed vocabulary. Use it to restrict a text model
+	// to the words picked by SelectFeatures.
+	VocabularyTokenizer struct {
+		Tokenizer  Tokenizer
+		Vocabulary map[string]bool
+	}
+)
+
+const (
+	// Pearson's chi-squared statistic of the word presence by class contingency table
+	ChiSquared SelectionCriterion = iota
+
+	// Mutual information in nats between word presence and class
+	MutualInformation
+)
+
+// Rank the words of a training set by how much their presence in a document
+// depends on the class of the document and return the k highest scoring words.
+// Each document counts a word at most once. Equal scores are ordered by word.
+func SelectFeatures(tokenizer Tokenizer, data map[string][]string, criterion SelectionCriterion, k int) ([]ScoredWord, error) {
+	if len(data) < 1 {
+		return nil, NoDataError
+	}
+	if k < 1 {
+		return nil, InvalidFeatureCountError
+	}
+
+	classes := make([]string, 0, len(data))
+	for class := range data {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	// Count the documents of each class, and the documents of each class containing each word
+	totalDocs := 0
+	classDocs := make([]int, len(classes))
+	docFrequency := make(map[string][]int)
+	for c, class := range classes {
+		classDocs[c] = len(data[class])
+		totalDocs += len(data[class])
+
+		for _, doc := range data[class] {
+			seen := make(map[string]bool)
+			for _, word := range tokenizer.Tokenize(doc) {
+				if seen[word] {
+					continue
+				}
+				seen[word] = true
+				if _, ok := docFrequency[word]; !ok {
+					docFrequency[word] = make([]int, len(classes))
+				}
+				docFrequency[word][c]++
+			}
+		}
+	}
+
+	scored := make([]ScoredWord, 0, len(docFrequency))
+	for word, df := range docFrequency {
+		scored = append(scored, ScoredWord{word, score(criterion, df, classDocs, totalDocs)})
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].Word < scored[j].Word
+	})
+
+	if k < len(scored) {
+		scored = scored[:k]
+	}
+	return scored, nil
+}
+
+// Score a word from the 2 x C table of documents with and without the word per class
+func score(criterion SelectionCriterion, df, classDocs []int, totalDocs int) float64 {
+	n := float64(totalDocs)
+	withWord := 0
+	for _, count := range df {
+		withWord += count
+	}
+
+	var sum float64
+	for c := range df {
+		// Observed number of documents in class c with and without the word
+		observed := [2]float64{float64(df[c]), float64(classDocs[c] - df[c])}
+		rowTotals := [2]float64{float64(withWord), float64(totalDocs - withWord)}
+
+		for r := 0; r < 2; r++ {
+			expected := rowTotals[r] * float64(classDocs[c]) / n
+			if expected == 0 {
+				continue
+			}
+			switch criterion {
+			case ChiSquared:
+				sum += (observed[r] - expected) * (observed[r] - expected) / expected
+			case MutualInformation:
+				if observed[r] > 0 {
+					sum += observed[r] / n * math.Log(observed[r]/expected)
+				}
+			}
+		}
+	}
+	return sum
+}
+
+// Construct a tokenizer which only keeps the given words
+func NewVocabularyTokenizer(tokenizer Tokenizer, words []ScoredWord) *VocabularyTokenizer {
+	vocabulary := make(map[string]bool, len(words))
+	for _, w := range words {
+		vocabulary[w.Word] = true
+	}
+	return &VocabularyTokenizer{Tokenizer: tokenizer, Vocabulary: vocabulary}
+}
+
+func (t *VocabularyTokenizer) Tokenize(doc string) []string {
+	var words []string
+	for _, word := range t.Tokenizer.Tokenize(doc) {
+		if t.Vocabulary[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
@@ -0,0 +1,76 @@
+package nba
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+var selectionData = map[string][]string{
+	"sports":  {"the goal was great", "the goal was late", "a goal"},
+	"finance": {"the stock was up", "the stock was down", "a stock"},
+}
+
+func TestSelectFeatures(t *testing.T) {
+	for _, criterion := range []SelectionCriterion{ChiSquared, MutualInformation} {
+		selected, err := SelectFeatures(NewWordTokenizer(), selectionData, criterion, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		words := []string{selected[0].Word, selected[1].Word}
+		if !reflect.DeepEqual(words, []string{"goal", "stock"}) {
+			t.Errorf("Criterion %v selected %v", criterion, selected)
+		}
+	}
+}
+
+func TestSelectFeaturesScores(t *testing.T) {
+	selected, err := SelectFeatures(NewWordTokenizer(), selectionData, ChiSquared, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scores := make(map[string]float64)
+	for _, w := range selected {
+		scores[w.Word] = w.Score
+	}
+	// goal perfectly separates the 6 documents so its chi-squared is N, the
+	// is in 4 documents evenly split between classes so it is independent
+	if math.Abs(scores["goal"]-6) > 1e-9 || scores["the"] != 0 {
+		t.Errorf("Unexpected chi-squared scores: %v", scores)
+	}
+
+	selected, err = SelectFeatures(NewWordTokenizer(), selectionData, MutualInformation, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A perfectly predictive word carries the full entropy of the two equally likely classes
+	if math.Abs(selected[0].Score-math.Log(2)) > 1e-9 {
+		t.Errorf("Unexpected mutual information: %v", selected[0])
+	}
+}
+
+func TestVocabularyTokenizer(t *testing.T) {
+	selected, err := SelectFeatures(NewWordTokenizer(), selectionData, ChiSquared, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	classifier := NewMultinomial(NewVocabularyTokenizer(NewWordTokenizer(), selected), 1)
+	if err := classifier.Fit(selectionData); err != nil {
+		t.Fatal(err)
+	}
+	if len(classifier.vocabulary) != 2 {
+		t.Errorf("Expected a vocabulary of 2 words, got %v", classifier.vocabulary)
+	}
+	if class, err := classifier.Classify("was the stock great"); class != "finance" {
+		t.Errorf("Failed to classify finance: class = %v, error = %v", class, err)
+	}
+}
+
+func TestSelectFeaturesInvalidCount(t *testing.T) {
+	if _, err := SelectFeatures(NewWordTokenizer(), selectionData, ChiSquared, 0); err != InvalidFeatureCountError {
+		t.Errorf("Expected InvalidFeatureCountError, got %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package nba
+
+import "io"
+
+// A DocumentStream yields labelled documents one at a time. Next returns
+// io.EOF once every document has been read.
+type DocumentStream interface {
+	Next() (class string, doc string, err error)
+}
+
+// Fit the model to documents read one at a time from a stream, so that
+// corpora which do not fit in memory can be used. Only the counts are kept.
+// IDF weighting needs the document frequencies of the whole corpus before
+// counting and is not supported. Documents are counted into a copy of the
+// model which replaces it once the stream ends, so classification sees either
+// the old or the new model and an error from the stream leaves the model
+// unchanged. Other fits of the model wait for the stream to end.
+func (nba *Multinomial) FitStream(stream DocumentStream) error {
+	nba.fitMu.Lock()
+	defer nba.fitMu.Unlock()
+
+	nba.mu.RLock()
+	fitted := nba.copyFitted()
+	nba.mu.RUnlock()
+
+	if fitted.Alpha <= 0 {
+		return InvalidSmoothingError
+	}
+	if fitted.vocabulary == nil && fitted.buckets < 1 {
+		return InvalidBucketsError
+	}
+	if fitted.IDF {
+		return StreamingIDFError
+	}
+
+	var totalDocs int = 0
+	docsForClass := make(map[string]int)
+	for {
+		class, doc, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		words := fitted.Tokenizer.Tokenize(doc)
+		fitted.learnVocabulary(words)
+		fitted.growCounts(class)
+		fitted.addCounts(class, fitted.features(words), 1)
+
+		totalDocs++
+		docsForClass[class]++
+	}
+	if totalDocs == 0 {
+		return NoDataError
+	}
+
+	// Counts are only grown while their class is read, give every class room for all words
+	for class := range fitted.wordCount {
+		fitted.growCounts(class)
+	}
+
+	// Calculate class priors
+	for class, docs := range docsForClass {
+		fitted.classPriors[class] = float64(docs) / float64(totalDocs)
+	}
+	fitted.classes = sortedClasses(fitted.classPriors)
+
+	fitted.vocabularySize = fitted.countSeenFeatures()
+
+	nba.mu.Lock()
+	defer nba.mu.Unlock()
+	nba.vocabulary = fitted.vocabulary
+	nba.vocabularySize = fitted.vocabularySize
+	nba.classSize = fitted.classSize
+	nba.classPriors = fitted.classPriors
+	nba.classes = fitted.classes
+	nba.wordCount = fitted.wordCount
+
+	return nil
+}
+
+// A copy of the model which can be fitted without changing the original
+func (nba *Multinomial) copyFitted() *Multinomial {
+	fitted := &Multinomial{
+		Tokenizer:      nba.Tokenizer,
+		Alpha:          nba.Alpha,
+		SublinearTF:    nba.SublinearTF,
+		IDF:            nba.IDF,
+		Normalize:      nba.Normalize,
+		buckets:        nba.buckets,
+		signed:         nba.signed,
+		vocabularySize: nba.vocabularySize,
+		classSize:      make(map[string]float64, len(nba.classSize)),
+		classPriors:    make(map[string]float64, len(nba.classPriors)),
+		classes:        nba.classes,
+		wordCount:      make(map[string][]float64, len(nba.wordCount)),
+		idf:            nba.idf,
+		idfDocs:        nba.idfDocs,
+	}
+	if nba.vocabulary != nil {
+		fitted.vocabulary = make(map[string]int, len(nba.vocabulary))
+		for word, i := range nba.vocabulary {
+			fitted.vocabulary[word] = i
+		}
+	}
+	for class, size := range nba.classSize {
+		fitted.classSize[class] = size
+	}
+	for class, prior := range nba.classPriors {
+		fitted.classPriors[class] = prior
+	}
+	for class, counts := range nba.wordCount {
+		fitted.wordCount[class] = append([]float64(nil), counts...)
+	}
+	return fitted
+}
@@ -0,0 +1,133 @@
+package nba
+
+import (
+	"strings"
+	"unicode"
+)
+
+type (
+	// A Tokenizer splits a document into the words used as features by a text
+	// model. Models tokenize documents concurrently, so Tokenize must be safe
+	// to call from multiple goroutines.
+	Tokenizer interface {
+		Tokenize(doc string) []string
+	}
+
+	// WordTokenizer segments documents on unicode letter and digit boundaries.
+	// Words can optionally be lower cased, filtered against a stop word list,
+	// stemmed and combined into n-grams, in that order.
+	WordTokenizer struct {
+		// Convert all words to lower case
+		Lowercase bool
+
+		// Words which are dropped before stemming and n-gram generation
+		StopWords map[string]bool
+
+		// Reduce each word to its stem using the Porter stemming algorithm
+		Stem bool
+
+		// The smallest and largest n-grams to generate, 1 and 1 only generates single words
+		MinN int
+		MaxN int
+	}
+)
+
+// A short list of common English function words
+var EnglishStopWords = wordSet(
+	"a", "about", "above", "after", "again", "against", "all", "am", "an", "and", "any", "are",
+	"as", "at", "be", "because", "been", "before", "being", "below", "between", "both", "but",
+	"by", "can", "could", "did", "do", "does", "doing", "down", "during", "each", "few", "for",
+	"from", "further", "had", "has", "have", "having", "he", "her", "here", "hers", "herself",
+	"him", "himself", "his", "how", "i", "if", "in", "into", "is", "it", "its", "itself", "just",
+	"me", "more", "most", "my", "myself", "no", "nor", "not", "now", "of", "off", "on", "once",
+	"only", "or", "other", "our", "ours", "ourselves", "out", "over", "own", "same", "she",
+	"should", "so", "some", "such", "than", "that", "the", "their", "theirs", "them",
+	"themselves", "then", "there", "these", "they", "this", "those", "through", "to", "too",
+	"under", "until", "up", "very", "was", "we", "were", "what", "when", "where", "which",
+	"while", "who", "whom", "why", "will", "with", "would", "you", "your", "yours", "yourself",
+	"yourselves",
+)
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// Construct a tokenizer which lower cases single words without stop word removal or stemming
+func NewWordTokenizer() *WordTokenizer {
+	return &WordTokenizer{
+		Lowercase: true,
+		MinN:      1,
+		MaxN:      1,
+	}
+}
+
+func (t *WordTokenizer) Tokenize(doc string) []string {
+	var words []string
+	for _, word := range segment(doc) {
+		if t.Lowercase {
+			word = strings.ToLower(word)
+		}
+		if t.StopWords[word] {
+			continue
+		}
+		if t.Stem {
+			word = PorterStem(word)
+		}
+		words = append(words, word)
+	}
+	return ngrams(words, t.MinN, t.MaxN)
+}
+
+// Split a document into runs of letters and digits. Apostrophes are kept
+// when surrounded by letters so that contractions form a single word.
+func segment(doc string) []string {
+	runes := []rune(doc)
+	isWordRune := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+	}
+
+	var words []string
+	start := -1
+	for i, r := range runes {
+		inWord := isWordRune(r)
+		if !inWord && (r == '\'' || r == '’') && start >= 0 && i+1 < len(runes) && unicode.IsLetter(runes[i+1]) {
+			inWord = true
+		}
+
+		if inWord && start < 0 {
+			start = i
+		} else if !inWord && start >= 0 {
+			words = append(words, string(runes[start:i]))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// Join consecutive words into n-grams of every length between min and max
+func ngrams(words []string, min, max int) []string {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	if min == 1 && max == 1 {
+		return words
+	}
+
+	var grams []string
+	for n := min; n <= max; n++ {
+		for i := 0; i+n <= len(words); i++ {
+			grams = append(grams, strings.Join(words[i:i+n], " "))
+		}
+	}
+	return grams
+}
@@ -0,0 +1,57 @@
+package nba
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWordTokenizer(t *testing.T) {
+	tokenizer := NewWordTokenizer()
+	words := tokenizer.Tokenize("Hello,\tWorld!\nIt's  café-time: 42 ")
+	expected := []string{"hello", "world", "it's", "café", "time", "42"}
+	if !reflect.DeepEqual(words, expected) {
+		t.Errorf("Unexpected words: %v", words)
+	}
+}
+
+func TestWordTokenizerStopWordsAndNGrams(t *testing.T) {
+	tokenizer := &WordTokenizer{
+		Lowercase: true,
+		StopWords: EnglishStopWords,
+		Stem:      true,
+		MinN:      1,
+		MaxN:      2,
+	}
+	words := tokenizer.Tokenize("The running dogs are barking")
+	expected := []string{"run", "dog", "bark", "run dog", "dog bark"}
+	if !reflect.DeepEqual(words, expected) {
+		t.Errorf("Unexpected words: %v", words)
+	}
+}
+
+func TestPorterStem(t *testing.T) {
+	for word, stem := range map[string]string{
+		"caresses":        "caress",
+		"ponies":          "poni",
+		"cats":            "cat",
+		"agreed":          "agre",
+		"plastered":       "plaster",
+		"motoring":        "motor",
+		"hopping":         "hop",
+		"filing":          "file",
+		"happy":           "happi",
+		"relational":      "relat",
+		"conditional":     "condit",
+		"generalizations": "gener",
+		"electrical":      "electr",
+		"adjustment":      "adjust",
+		"adoption":        "adopt",
+		"controlling":     "control",
+		"roll":            "roll",
+		"is":              "is",
+	} {
+		if res := PorterStem(word); res != stem {
+			t.Errorf("PorterStem(%q) = %q, expected %q", word, res, stem)
+		}
+	}
+}
@@ -1,9 +1,116 @@
 package nba
 
-import "errors"
+import (
+	"errors"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+)
 
 var (
-	WrongDimensionError   = errors.New("Dimensionality of data does not match prior data")
-	NoDataError           = errors.New("Cannot fit model without training data")
-	NoClassificationError = errors.New("No Class was found for this data point")
+	WrongDimensionError          = errors.New("Dimensionality of data does not match prior data")
+	NoDataError                  = errors.New("Cannot fit model without training data")
+	NoClassificationError        = errors.New("No Class was found for this data point")
+	InvalidSmoothingError        = errors.New("Smoothing parameter must be greater than 0")
+	InvalidBucketsError          = errors.New("Number of hash buckets must be greater than 0")
+	InvalidFeatureCountError     = errors.New("Number of features to select must be greater than 0")
+	UnknownClassError            = errors.New("Class was not part of the training data")
+	NoVocabularyError            = errors.New("Words cannot be recovered from a hashing model")
+	UnknownFormatError           = errors.New("Unknown model encoding format")
+	UnsupportedVersionError      = errors.New("Saved model version is not supported by this package")
+	UnsupportedTokenizerError    = errors.New("Only tokenizers provided by this package can be saved")
+	UnsupportedDistributionError = errors.New("Only distributions provided by this package can be saved")
+	WeightMismatchError          = errors.New("Number of weights does not match number of points")
+	InvalidWeightError           = errors.New("Weights must not be negative and must not all be 0")
+	MissingFeatureGroupError     = errors.New("Record is missing a feature group of the schema")
+	UnknownFeatureKindError      = errors.New("Unknown feature group kind")
+	DuplicateFeatureGroupError   = errors.New("Feature group names must be unique within a schema")
+	MissingTokenizerError        = errors.New("Text feature groups need a tokenizer")
+	InvalidDimensionalityError   = errors.New("Feature groups need a dimensionality of at least 1")
+	InvalidEMOptionsError        = errors.New("Unlabelled weight must be between 0 and 1, tolerance non negative and iterations at least 1")
+	LenMismatchError             = errors.New("Number of documents does not match number of label sets")
+	NotFittedError               = errors.New("Model has not been fit to any data")
+	InvalidPriorsError           = errors.New("Priors must be non negative, sum to 1 and include every class")
+	UnderflowError               = errors.New("Data has a probability of 0 under every class")
+	StreamingIDFError            = errors.New("IDF weighting needs the whole corpus up front and cannot be fit from a stream")
 )
+
+// Pick the class with the highest posterior given the log likelihood of the
+// data under each class. Classes must be sorted and a tie goes to the class
+// whose name sorts first, so results do not change between runs.
+func classify(classes []string, classPriors map[string]float64, logLikelihoods map[string]float64) (string, error) {
+	if len(classes) == 0 {
+		return "", NoClassificationError
+	}
+
+	var bestClass string
+	bestClassLogProbability := math.Inf(-1)
+
+	for _, class := range classes {
+		// Bayes theorem: P(c|e) = (P(e|c)P(c)) / P(e)
+		// We drop P(e) as it is constant
+		logProbability := logLikelihoods[class] + math.Log(classPriors[class])
+
+		// Update current best class, only a strictly better class replaces an earlier one
+		if logProbability > bestClassLogProbability {
+			bestClassLogProbability = logProbability
+			bestClass = class
+		}
+	}
+
+	// Every class gave the data a probability of 0, or NaN, so there is no best class
+	if math.IsInf(bestClassLogProbability, -1) {
+		return "", UnderflowError
+	}
+
+	return bestClass, nil
+}
+
+// The classes of a model in sorted order
+func sortedClasses(classPriors map[string]float64) []string {
+	classes := make([]string, 0, len(classPriors))
+	for class := range classPriors {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+// The posterior probability of each class and the log of the evidence P(e)
+// which normalizes them, computed in log space to avoid underflow
+func posteriors(classes []string, classPriors map[string]float64, logLikelihoods map[string]float64) (map[string]float64, float64) {
+	max := math.Inf(-1)
+	for _, class := range classes {
+		max = math.Max(max, logLikelihoods[class]+math.Log(classPriors[class]))
+	}
+
+	var sum float64
+	for _, class := range classes {
+		sum += math.Exp(logLikelihoods[class] + math.Log(classPriors[class]) - max)
+	}
+
+	posteriors := make(map[string]float64, len(classPriors))
+	for class, classPrior := range classPriors {
+		posteriors[class] = math.Exp(logLikelihoods[class]+math.Log(classPrior)-max) / sum
+	}
+	return posteriors, max + math.Log(sum)
+}
+
+// Split [0, n) into one contiguous chunk per available processor and call f
+// on every chunk concurrently, returning once all chunks are done
+func parallel(n int, f func(start, end int)) {
+	chunks := runtime.GOMAXPROCS(0)
+	if n < chunks {
+		chunks = n
+	}
+	var wg sync.WaitGroup
+	for c := 0; c < chunks; c++ {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			f(c*n/chunks, (c+1)*n/chunks)
+		}(c)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,51 @@
+package nba
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestClassifyTiesGoToFirstClass(t *testing.T) {
+	classifier := NewMultinomial(NewWordTokenizer(), 1)
+	if err := classifier.Fit(map[string][]string{
+		"c": {"same words"},
+		"a": {"same words"},
+		"b": {"same words"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 20; i++ {
+		if class, err := classifier.Classify("same"); class != "a" {
+			t.Fatalf("Expected tie to go to a: class = %v, error = %v", class, err)
+		}
+	}
+
+	// Classes added by a later fit are kept in sorted order
+	if err := classifier.Fit(map[string][]string{"0": {"other words"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(classifier.classes, []string{"0", "a", "b", "c"}) {
+		t.Errorf("Expected sorted classes, got %v", classifier.classes)
+	}
+}
+
+func TestClassifyUnderflow(t *testing.T) {
+	classes := []string{"a", "b"}
+	priors := map[string]float64{"a": 0.5, "b": 0.5}
+	logLikelihoods := map[string]float64{"a": math.Inf(-1), "b": math.Inf(-1)}
+	if _, err := classify(classes, priors, logLikelihoods); err != UnderflowError {
+		t.Errorf("Expected UnderflowError, got %v", err)
+	}
+
+	// A class with a finite probability still wins, however small
+	logLikelihoods["b"] = -math.MaxFloat64
+	if class, err := classify(classes, priors, logLikelihoods); err != nil || class != "b" {
+		t.Errorf("Expected b, got class = %v, error = %v", class, err)
+	}
+
+	if _, err := classify(nil, map[string]float64{}, logLikelihoods); err != NoClassificationError {
+		t.Errorf("Expected NoClassificationError, got %v", err)
+	}
+}